@@ -0,0 +1,775 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// blackjackBetOptionMinValue is the smallest bet, two chips so that surrendering gives back at least one
+var blackjackBetOptionMinValue = 2.0
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: Blackjack,
+		Name: "Blackjack",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "blackjack",
+			Description: "Start a game of Blackjack against the dealer.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "decks",
+					Description: "How many decks are in the shoe (defaults to the server's deck count)",
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    maxDecksOptionValue,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "hit-soft-17",
+					Description: "Whether the dealer hits on a soft 17 (default false)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "payout",
+					Description: "What a blackjack pays (default 3:2)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "3:2", Value: "3:2"},
+						{Name: "6:5", Value: "6:5"},
+						{Name: "1:1", Value: "1:1"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "chips",
+					Description: fmt.Sprintf("How many chips each player starts with (default %d)", DefaultBlackjackChips),
+					MinValue:    &integerOptionMinValue,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "bet",
+					Description: fmt.Sprintf("The bet for each hand (default %d)", DefaultBlackjackBet),
+					MinValue:    &blackjackBetOptionMinValue,
+				},
+			},
+		},
+		CommandHandler:   blackjackCommand,
+		ComponentPrefix:  "blackjack",
+		ComponentHandler: blackjackComponent,
+	})
+}
+
+// Defaults and limits for Blackjack tables
+const (
+	DefaultBlackjackChips = 1000
+	DefaultBlackjackBet   = 10
+	MaxBlackjackPlayers   = 7
+	maxBlackjackHands     = 4
+	blackjackTurnTimeout  = 60 * time.Second
+)
+
+// Results of a single blackjack hand once the round is settled
+const (
+	blackjackLost int = iota
+	blackjackPushed
+	blackjackWon
+	blackjackPaidNatural
+	blackjackSurrendered
+)
+
+// blackjackHand is one of a player's hands; splitting creates more of them
+type blackjackHand struct {
+	cards       playingcards.Hand
+	bet         int
+	stood       bool
+	doubled     bool
+	surrendered bool
+	fromSplit   bool
+	result      int
+}
+
+func (h *blackjackHand) total() int {
+	total, _ := h.cards.BlackjackTotal()
+	return total
+}
+
+// done returns whether the player can no longer act on the hand
+func (h *blackjackHand) done() bool {
+	return h.stood || h.surrendered || h.total() >= 21
+}
+
+// natural returns whether the hand is a blackjack dealt on the first two cards, which split hands can't be
+func (h *blackjackHand) natural() bool {
+	return !h.fromSplit && playingcards.IsBlackjack(h.cards.Cards())
+}
+
+type blackjackPlayer struct {
+	userID string
+	chips  int
+	hands  []*blackjackHand
+}
+
+// BlackjackTable holds the state of a game of Blackjack against the bot dealer
+type BlackjackTable struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+	// deck points at the channel's deck, which is used as the dealing shoe.
+	// While the game runs, the deck is guarded by the table's lock instead of the server's.
+	deck *playingcards.Deck
+
+	numDecks      int
+	hitSoft17     bool
+	payoutNum     int
+	payoutDen     int
+	bet           int
+	startingChips int
+
+	players     []*blackjackPlayer
+	dealer      playingcards.Hand
+	roundNumber int
+	inRound     bool
+	turn        int
+	hand        int
+	turnToken   int
+	closed      bool
+	log         []string
+}
+
+// NewBlackjackTable creates a table that deals from the given channel's deck
+func NewBlackjackTable(state *ServerState, channel *ChannelState, numDecks int, hitSoft17 bool, payoutNum int, payoutDen int, chips int, bet int) *BlackjackTable {
+	return &BlackjackTable{
+		guildID:       state.id,
+		channelID:     channel.id,
+		cardsStyle:    state.cardsStyle,
+		deck:          &channel.deck,
+		numDecks:      numDecks,
+		hitSoft17:     hitSoft17,
+		payoutNum:     payoutNum,
+		payoutDen:     payoutDen,
+		bet:           bet,
+		startingChips: chips,
+	}
+}
+
+// Lock locks the table's state
+func (t *BlackjackTable) Lock() {
+	t.mu.Lock()
+}
+
+// Unlock unlocks the table's state
+func (t *BlackjackTable) Unlock() {
+	t.mu.Unlock()
+}
+
+// End stops the table, so pending timers and button presses are ignored
+func (t *BlackjackTable) End() {
+	t.closed = true
+}
+
+func (t *BlackjackTable) playerIndex(userID string) int {
+	for i, player := range t.players {
+		if player.userID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Join seats a new player at the table
+func (t *BlackjackTable) Join(userID string) error {
+	if t.playerIndex(userID) >= 0 {
+		return errors.New("You are already seated at the table.")
+	}
+	if len(t.players) >= MaxBlackjackPlayers {
+		return errors.New("The table is full.")
+	}
+	t.players = append(t.players, &blackjackPlayer{userID: userID, chips: t.startingChips})
+	return nil
+}
+
+func (t *BlackjackTable) leave(userID string) error {
+	i := t.playerIndex(userID)
+	if i < 0 {
+		return errors.New("You are not seated at this table.")
+	}
+	if t.inRound {
+		return errors.New("You can't leave in the middle of a round.")
+	}
+	t.players = append(t.players[:i], t.players[i+1:]...)
+	return nil
+}
+
+// newShoe replaces the channel's deck with a freshly shuffled shoe for the table
+func (t *BlackjackTable) newShoe() {
+	*t.deck = playingcards.NewShoe(t.numDecks, false) // Blackjack does not use Joker cards
+	t.deck.SetPenetration(DefaultPenetration)
+	t.deck.Shuffle()
+}
+
+func (t *BlackjackTable) drawCard() playingcards.Card {
+	if t.deck.Size() == 0 {
+		t.newShoe()
+		t.log = append(t.log, "The shoe ran out and was reshuffled.")
+	}
+	return t.deck.DrawCard()
+}
+
+// startRound takes everyone's bets and deals the first two cards.
+// It returns true if no player needs to act, so the round can be settled right away.
+func (t *BlackjackTable) startRound() (bool, error) {
+	playing := 0
+	for _, player := range t.players {
+		player.hands = nil
+		if player.chips >= t.bet {
+			playing++
+		}
+	}
+	if playing == 0 {
+		return false, errors.New("Nobody at the table has enough chips to bet.")
+	}
+	t.log = nil
+	if t.deck.NumDecks() != t.numDecks || t.deck.CutCardReached() || t.deck.Size() == 0 || t.roundNumber == 0 {
+		t.newShoe()
+		if t.roundNumber > 0 {
+			t.log = append(t.log, "The cut card was reached, so the shoe was reshuffled.")
+		}
+	}
+	t.roundNumber++
+	t.inRound = true
+	t.dealer = playingcards.NewHand()
+
+	for _, player := range t.players {
+		if player.chips >= t.bet {
+			player.chips -= t.bet
+			player.hands = []*blackjackHand{{bet: t.bet}}
+		}
+	}
+	for round := 0; round < 2; round++ {
+		for _, player := range t.players {
+			for _, hand := range player.hands {
+				hand.cards.Add(t.drawCard())
+			}
+		}
+		t.dealer.Add(t.drawCard())
+	}
+
+	// The dealer peeks for blackjack when showing an ace or a ten-valued card
+	if playingcards.BlackjackValue(t.dealer.Card(0)) >= 10 || t.dealer.Card(0).Value() == 1 {
+		if playingcards.IsBlackjack(t.dealer.Cards()) {
+			t.log = append(t.log, "The dealer has blackjack!")
+			return true, nil
+		}
+	}
+	t.turn, t.hand = 0, -1
+	return t.passTurn(), nil
+}
+
+// passTurn moves on to the next hand that still needs decisions. It returns true once every hand is done.
+func (t *BlackjackTable) passTurn() bool {
+	t.turnToken++
+	for t.turn < len(t.players) {
+		hands := t.players[t.turn].hands
+		for t.hand+1 < len(hands) {
+			t.hand++
+			if !hands[t.hand].done() {
+				return false
+			}
+		}
+		t.turn++
+		t.hand = -1
+	}
+	return true
+}
+
+// current returns the player and hand whose turn it is
+func (t *BlackjackTable) current() (*blackjackPlayer, *blackjackHand) {
+	player := t.players[t.turn]
+	return player, player.hands[t.hand]
+}
+
+// Actions a player can take on a blackjack hand
+const (
+	blackjackHit int = iota
+	blackjackStand
+	blackjackDouble
+	blackjackSplit
+	blackjackSurrender
+)
+
+// Start deals a new round when a seated player presses Deal.
+// It returns true if no player needs to act, so the round can be settled right away.
+func (t *BlackjackTable) Start(userID string) (bool, error) {
+	if t.inRound {
+		return false, errors.New("A round is already in progress.")
+	}
+	if t.playerIndex(userID) < 0 {
+		return false, errors.New("Only seated players can deal.")
+	}
+	return t.startRound()
+}
+
+// blackjackActions maps the names of the action buttons to the actions
+var blackjackActions = map[string]int{
+	"hit":       blackjackHit,
+	"stand":     blackjackStand,
+	"double":    blackjackDouble,
+	"split":     blackjackSplit,
+	"surrender": blackjackSurrender,
+}
+
+// Action applies a player's "hit", "stand", "double", "split" or "surrender" to their current hand.
+// It returns true once every hand is done.
+func (t *BlackjackTable) Action(userID string, action string, amount int) (bool, error) {
+	blackjackAction, ok := blackjackActions[action]
+	if !ok {
+		return false, errors.New("Unknown action.")
+	}
+	return t.act(userID, blackjackAction)
+}
+
+// Timeout stands the current hand of the player who took too long. It returns true once every hand is done.
+func (t *BlackjackTable) Timeout() (bool, error) {
+	if !t.inRound {
+		return false, errors.New("The round hasn't started yet.")
+	}
+	player, _ := t.current()
+	t.log = append(t.log, fmt.Sprintf("%s ran out of time and stands.", mention(player.userID)))
+	return t.act(player.userID, blackjackStand)
+}
+
+// act applies a player's decision to their current hand. It returns true once every hand is done.
+func (t *BlackjackTable) act(userID string, action int) (bool, error) {
+	if !t.inRound {
+		return false, errors.New("The round hasn't started yet.")
+	}
+	player, hand := t.current()
+	if player.userID != userID {
+		if t.playerIndex(userID) < 0 {
+			return false, errors.New("You are not seated at this table.")
+		}
+		return false, errors.New("It's not your turn.")
+	}
+	firstDecision := hand.cards.Size() == 2 && !hand.doubled
+
+	switch action {
+	case blackjackHit:
+		hand.cards.Add(t.drawCard())
+	case blackjackStand:
+		hand.stood = true
+	case blackjackDouble:
+		if !firstDecision {
+			return false, errors.New("You can only double down on your first two cards.")
+		}
+		if player.chips < hand.bet {
+			return false, errors.New("You don't have enough chips to double down.")
+		}
+		player.chips -= hand.bet
+		hand.bet *= 2
+		hand.doubled = true
+		hand.cards.Add(t.drawCard())
+		hand.stood = true
+	case blackjackSplit:
+		if !firstDecision || hand.cards.Card(0).Value() != hand.cards.Card(1).Value() {
+			return false, errors.New("You can only split a pair.")
+		}
+		if len(player.hands) >= maxBlackjackHands {
+			return false, fmt.Errorf("You can't have more than %d hands.", maxBlackjackHands)
+		}
+		if player.chips < hand.bet {
+			return false, errors.New("You don't have enough chips to split.")
+		}
+		player.chips -= hand.bet
+		splitCard, _ := hand.cards.RemoveAt(1)
+		newHand := &blackjackHand{cards: playingcards.NewHand(splitCard), bet: hand.bet, fromSplit: true}
+		hand.fromSplit = true
+		hand.cards.Add(t.drawCard())
+		newHand.cards.Add(t.drawCard())
+		// Split aces only get one more card each
+		if splitCard.Value() == 1 {
+			hand.stood = true
+			newHand.stood = true
+		}
+		player.hands = append(player.hands[:t.hand+1], append([]*blackjackHand{newHand}, player.hands[t.hand+1:]...)...)
+	case blackjackSurrender:
+		if !firstDecision || len(player.hands) > 1 {
+			return false, errors.New("You can only surrender on your first two cards.")
+		}
+		hand.surrendered = true
+	}
+
+	if !hand.done() {
+		t.turnToken++
+		return false, nil
+	}
+	return t.passTurn(), nil
+}
+
+// finishRound plays out the dealer's hand and pays out every hand
+func (t *BlackjackTable) finishRound() {
+	t.inRound = false
+	live := false
+	for _, player := range t.players {
+		for _, hand := range player.hands {
+			if !hand.surrendered && hand.total() <= 21 && !hand.natural() {
+				live = true
+			}
+		}
+	}
+	dealerNatural := playingcards.IsBlackjack(t.dealer.Cards())
+	if live && !dealerNatural {
+		for playingcards.DealerShouldHit(t.dealer.Cards(), t.hitSoft17) {
+			t.dealer.Add(t.drawCard())
+		}
+	}
+	dealerTotal, _ := t.dealer.BlackjackTotal()
+
+	for _, player := range t.players {
+		for _, hand := range player.hands {
+			total := hand.total()
+			switch {
+			case hand.surrendered:
+				hand.result = blackjackSurrendered
+				player.chips += hand.bet / 2
+			case total > 21:
+				hand.result = blackjackLost
+			case hand.natural() && dealerNatural:
+				hand.result = blackjackPushed
+				player.chips += hand.bet
+			case hand.natural():
+				hand.result = blackjackPaidNatural
+				player.chips += hand.bet + hand.bet*t.payoutNum/t.payoutDen
+			case dealerNatural:
+				hand.result = blackjackLost
+			case dealerTotal > 21 || total > dealerTotal:
+				hand.result = blackjackWon
+				player.chips += hand.bet * 2
+			case total == dealerTotal:
+				hand.result = blackjackPushed
+				player.chips += hand.bet
+			default:
+				hand.result = blackjackLost
+			}
+		}
+	}
+}
+
+// Discord side of the game
+
+// blackjackCommand handles the /blackjack slash command by opening a new table for players to join
+func blackjackCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	numDecks, hitSoft17 := state.numDecks, false
+	payoutNum, payoutDen := 3, 2
+	chips, bet := DefaultBlackjackChips, DefaultBlackjackBet
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "decks":
+			numDecks = int(opt.IntValue())
+		case "hit-soft-17":
+			hitSoft17 = opt.BoolValue()
+		case "payout":
+			fmt.Sscanf(opt.StringValue(), "%d:%d", &payoutNum, &payoutDen)
+		case "chips":
+			chips = int(opt.IntValue())
+		case "bet":
+			bet = int(opt.IntValue())
+		}
+	}
+	if payoutNum < 1 || payoutDen < 1 {
+		payoutNum, payoutDen = 3, 2
+	}
+	if chips < bet {
+		respondEphemeral(s, i, "Players need to start with enough chips for at least one bet.")
+		return
+	}
+
+	table := NewBlackjackTable(state, channel, numDecks, hitSoft17, payoutNum, payoutDen, chips, bet)
+	table.Join(interactionUserID(i))
+	channel.game = GameState{gameType: Blackjack, current: table}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{table.lobbyEmbed()},
+			Components: blackjackLobbyComponents(),
+		},
+	})
+}
+
+func (t *BlackjackTable) rulesText() string {
+	dealerRule := "Dealer stands on soft 17"
+	if t.hitSoft17 {
+		dealerRule = "Dealer hits soft 17"
+	}
+	return fmt.Sprintf("%d deck(s), %s, blackjack pays %d:%d, bet %d", t.numDecks, dealerRule, t.payoutNum, t.payoutDen, t.bet)
+}
+
+func (t *BlackjackTable) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	for _, player := range t.players {
+		players.WriteString(fmt.Sprintf("%s: %d chips\n", mention(player.userID), player.chips))
+	}
+	if len(t.players) == 0 {
+		players.WriteString("Nobody")
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "Blackjack",
+		Description: "Press **Join** to take a seat, then any seated player can press **Deal** to start the round.",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", len(t.players), MaxBlackjackPlayers),
+				Value: players.String(),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: t.rulesText(),
+		},
+	}
+}
+
+func blackjackLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "blackjack:join"},
+				discordgo.Button{Label: "Leave", Style: discordgo.SecondaryButton, CustomID: "blackjack:leave"},
+				discordgo.Button{Label: "Deal", Style: discordgo.PrimaryButton, CustomID: "blackjack:deal"},
+			},
+		},
+	}
+}
+
+// blackjackComponent handles the buttons of a Blackjack game
+func blackjackComponent(s Session, i *discordgo.InteractionCreate) {
+	table, _ := runningGame(i, Blackjack).(*BlackjackTable)
+	if table == nil {
+		respondEphemeral(s, i, "This game of Blackjack is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	if table.closed {
+		respondEphemeral(s, i, "This game of Blackjack is no longer running.")
+		return
+	}
+
+	switch i.MessageComponentData().CustomID {
+	case "blackjack:join", "blackjack:leave":
+		var err error
+		if i.MessageComponentData().CustomID == "blackjack:join" {
+			err = table.Join(userID)
+		} else {
+			err = table.leave(userID)
+		}
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		if len(table.players) == 0 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: &discordgo.InteractionResponseData{
+					Content:    "Everyone left the table, so the game has ended.",
+					Embeds:     []*discordgo.MessageEmbed{},
+					Components: []discordgo.MessageComponent{},
+				},
+			})
+			table.end()
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{table.lobbyEmbed()},
+				Components: blackjackLobbyComponents(),
+			},
+		})
+	case "blackjack:deal":
+		roundOver, err := table.Start(userID)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		// Take the buttons off the previous message, the new round gets its own
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		embeds, components := table.render()
+		message, err := s.ChannelMessageSendComplex(table.channelID, &discordgo.MessageSend{
+			Embeds:     embeds,
+			Components: components,
+		})
+		if err != nil {
+			s.ChannelMessageSend(table.channelID, "Error found while running the game. Exiting...")
+			table.end()
+			return
+		}
+		table.messageID = message.ID
+		table.afterAction(s, roundOver)
+	case "blackjack:hit", "blackjack:stand", "blackjack:double", "blackjack:split", "blackjack:surrender":
+		table.handleAction(s, i, userID, strings.TrimPrefix(i.MessageComponentData().CustomID, "blackjack:"))
+	}
+}
+
+// end stops the game and resets its channel. The table must be locked.
+func (t *BlackjackTable) end() {
+	t.End()
+	endGame(t.guildID, t.channelID, t)
+}
+
+// handleAction applies a player's decision and updates the round message. The table must be locked.
+func (t *BlackjackTable) handleAction(s Session, i *discordgo.InteractionCreate, userID string, action string) {
+	roundOver, err := t.Action(userID, action, 0)
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	t.afterAction(s, roundOver)
+}
+
+// afterAction refreshes the round message, settling the round once every hand is done. The table must be locked.
+func (t *BlackjackTable) afterAction(s Session, roundOver bool) {
+	if roundOver {
+		t.finishRound()
+	} else {
+		t.scheduleTimeout(s)
+	}
+	embeds, components := t.render()
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         t.messageID,
+		Channel:    t.channelID,
+		Embeds:     embeds,
+		Components: components,
+	})
+}
+
+// scheduleTimeout stands the current hand if the player takes too long. The table must be locked.
+func (t *BlackjackTable) scheduleTimeout(s Session) {
+	token := t.turnToken
+	time.AfterFunc(blackjackTurnTimeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.closed || !t.inRound || t.turnToken != token {
+			return
+		}
+		roundOver, err := t.Timeout()
+		if err != nil {
+			return
+		}
+		t.afterAction(s, roundOver)
+	})
+}
+
+func blackjackCardsText(cards []playingcards.Card) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// render builds the round message: the dealer's cards, every player's hands and the buttons for the current hand
+func (t *BlackjackTable) render() ([]*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var description strings.Builder
+	dealerCards := t.dealer.Cards()
+	if t.inRound {
+		description.WriteString(fmt.Sprintf("**Dealer**: %s, 🂠\n\n", dealerCards[0]))
+	} else {
+		dealerTotal, _ := t.dealer.BlackjackTotal()
+		description.WriteString(fmt.Sprintf("**Dealer**: %s (%d)\n\n", blackjackCardsText(dealerCards), dealerTotal))
+	}
+
+	for p, player := range t.players {
+		if len(player.hands) == 0 {
+			continue
+		}
+		description.WriteString(fmt.Sprintf("%s (%d chips)\n", mention(player.userID), player.chips))
+		for h, hand := range player.hands {
+			marker := "▫️"
+			if t.inRound && p == t.turn && h == t.hand {
+				marker = "▶️"
+			}
+			total, soft := hand.cards.BlackjackTotal()
+			totalText := fmt.Sprintf("%d", total)
+			if soft && total < 21 {
+				totalText = "soft " + totalText
+			}
+			status := ""
+			switch {
+			case !t.inRound:
+				status = [...]string{"lost", "push", "won", "blackjack!", "surrendered"}[hand.result]
+			case hand.surrendered:
+				status = "surrendered"
+			case total > 21:
+				status = "bust"
+			case hand.natural():
+				status = "blackjack!"
+			case hand.doubled:
+				status = "doubled"
+			}
+			if status != "" {
+				status = ", " + status
+			}
+			description.WriteString(fmt.Sprintf("%s %s (%s, bet %d%s)\n", marker, blackjackCardsText(hand.cards.Cards()), totalText, hand.bet, status))
+		}
+		description.WriteString("\n")
+	}
+	if len(t.log) > 0 {
+		description.WriteString(strings.Join(t.log, "\n"))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Blackjack: Round #%d", t.roundNumber),
+		Description: description.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s. %d cards left in the shoe.", t.rulesText(), t.deck.Size()),
+		},
+	}
+	if t.inRound {
+		player, hand := t.current()
+		cards := hand.cards.Cards()
+		embed.Description += fmt.Sprintf("\n%s to act.", mention(player.userID))
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetCardURL(cards[len(cards)-1], t.cardsStyle)}
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: GetCardURL(dealerCards[0], t.cardsStyle)}
+		return []*discordgo.MessageEmbed{embed}, []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Hit", Style: discordgo.SuccessButton, CustomID: "blackjack:hit"},
+					discordgo.Button{Label: "Stand", Style: discordgo.DangerButton, CustomID: "blackjack:stand"},
+					discordgo.Button{Label: "Double", Style: discordgo.PrimaryButton, CustomID: "blackjack:double"},
+					discordgo.Button{Label: "Split", Style: discordgo.PrimaryButton, CustomID: "blackjack:split"},
+					discordgo.Button{Label: "Surrender", Style: discordgo.SecondaryButton, CustomID: "blackjack:surrender"},
+				},
+			},
+		}
+	}
+	embed.Image = &discordgo.MessageEmbedImage{URL: GetCardURL(dealerCards[len(dealerCards)-1], t.cardsStyle)}
+	return []*discordgo.MessageEmbed{embed, t.lobbyEmbed()}, blackjackLobbyComponents()
+}
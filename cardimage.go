@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// Layouts for the images made by a CardRenderer
+const (
+	// LayoutRow places the cards side by side, starting a new row every cardsPerRow cards
+	LayoutRow = "row"
+	// LayoutFan overlaps the cards in an arc, like a hand held by a player
+	LayoutFan = "fan"
+)
+
+// MaxRenderCount is the most cards a CardRenderer puts in one image. Messages list larger hands as text instead.
+const MaxRenderCount = 52
+
+const (
+	cardsPerRow = 13
+	cardSpacing = 8
+	// maxFanAngle is the angle in degrees between the outermost cards of a fan
+	maxFanAngle = 60.0
+	// fanStep is the largest angle in degrees between two neighbouring cards of a fan
+	fanStep = 8.0
+	// maxRenderedImages is how many rendered images are kept in the cache
+	maxRenderedImages = 256
+)
+
+// CardRenderer composes several cards into a single PNG image, caching the card images it loads
+// and the images it renders. It is safe to use from several goroutines.
+type CardRenderer struct {
+	mu    sync.Mutex
+	cards map[string]*image.RGBA
+	// rendered holds encoded images keyed by style, layout and cards, and renderOrder their keys oldest first
+	rendered    map[string][]byte
+	renderOrder []string
+}
+
+// NewCardRenderer creates a renderer with empty caches
+func NewCardRenderer() *CardRenderer {
+	return &CardRenderer{
+		cards:    make(map[string]*image.RGBA),
+		rendered: make(map[string][]byte),
+	}
+}
+
+// renderer is used by the bot's commands and by the /hands/ HTTP handler
+var renderer = NewCardRenderer()
+
+// cardImage returns the image of a card, reading it from card_images the first time. The renderer must be locked.
+func (r *CardRenderer) cardImage(card playingcards.Card, style int) (*image.RGBA, error) {
+	path := GetCardPath(card, style)
+	if path == "" {
+		return nil, fmt.Errorf("no image for %s", card.String())
+	}
+	return r.loadImage(path)
+}
+
+// loadImage returns the image at the given path, reading it the first time. The renderer must be locked.
+func (r *CardRenderer) loadImage(path string) (*image.RGBA, error) {
+	if img, ok := r.cards[path]; ok {
+		return img, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	bounds := decoded.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), decoded, bounds.Min, draw.Src)
+	r.cards[path] = img
+	return img, nil
+}
+
+// cache keeps a rendered image, forgetting the oldest one once the cache is full. The renderer must be locked.
+func (r *CardRenderer) cache(key string, data []byte) {
+	r.rendered[key] = data
+	r.renderOrder = append(r.renderOrder, key)
+	if len(r.renderOrder) > maxRenderedImages {
+		delete(r.rendered, r.renderOrder[0])
+		r.renderOrder = r.renderOrder[1:]
+	}
+}
+
+// Render returns the PNG image of the cards in the given style and layout
+func (r *CardRenderer) Render(cards []playingcards.Card, style int, layout string) ([]byte, error) {
+	if len(cards) == 0 {
+		return nil, errors.New("no cards to render")
+	}
+	if len(cards) > MaxRenderCount {
+		return nil, fmt.Errorf("can't render more than %d cards", MaxRenderCount)
+	}
+	key := fmt.Sprintf("%d/%s/%s", style, layout, cardCodes(cards))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data, ok := r.rendered[key]; ok {
+		return data, nil
+	}
+	images := make([]*image.RGBA, len(cards))
+	for i, card := range cards {
+		img, err := r.cardImage(card, style)
+		if err != nil {
+			return nil, err
+		}
+		images[i] = img
+	}
+
+	var canvas *image.RGBA
+	switch layout {
+	case LayoutRow:
+		canvas = renderRow(images)
+	case LayoutFan:
+		canvas = renderFan(images)
+	default:
+		return nil, fmt.Errorf("unknown layout %q", layout)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+
+	data := buf.Bytes()
+	r.cache(key, data)
+	return data, nil
+}
+
+// cardSize returns the size of the largest of the images
+func cardSize(images []*image.RGBA) (int, int) {
+	width, height := 0, 0
+	for _, img := range images {
+		if img.Bounds().Dx() > width {
+			width = img.Bounds().Dx()
+		}
+		if img.Bounds().Dy() > height {
+			height = img.Bounds().Dy()
+		}
+	}
+	return width, height
+}
+
+func renderRow(images []*image.RGBA) *image.RGBA {
+	cardWidth, cardHeight := cardSize(images)
+	columns := minInt(len(images), cardsPerRow)
+	rows := (len(images) + cardsPerRow - 1) / cardsPerRow
+	canvas := image.NewRGBA(image.Rect(0, 0, columns*(cardWidth+cardSpacing)+cardSpacing, rows*(cardHeight+cardSpacing)+cardSpacing))
+	for i, img := range images {
+		x := cardSpacing + (i%cardsPerRow)*(cardWidth+cardSpacing)
+		y := cardSpacing + (i/cardsPerRow)*(cardHeight+cardSpacing)
+		draw.Draw(canvas, img.Bounds().Add(image.Pt(x, y)), img, image.Point{}, draw.Over)
+	}
+	return canvas
+}
+
+func renderFan(images []*image.RGBA) *image.RGBA {
+	cardWidth, cardHeight := cardSize(images)
+	step := fanStep
+	if len(images) > 1 && step*float64(len(images)-1) > maxFanAngle {
+		step = maxFanAngle / float64(len(images)-1)
+	}
+	// The cards turn around a point below the middle of the hand
+	radius := float64(cardHeight) * 2
+	angles := make([]float64, len(images))
+	centers := make([][2]float64, len(images))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range images {
+		angles[i] = (float64(i) - float64(len(images)-1)/2) * step * math.Pi / 180
+		sin, cos := math.Sincos(angles[i])
+		centers[i] = [2]float64{radius * sin, -radius * cos}
+		for _, corner := range [][2]float64{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			dx, dy := corner[0]*float64(cardWidth)/2, corner[1]*float64(cardHeight)/2
+			x := centers[i][0] + dx*cos - dy*sin
+			y := centers[i][1] + dx*sin + dy*cos
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+
+	offsetX, offsetY := cardSpacing-minX, cardSpacing-minY
+	canvas := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(maxX-minX))+2*cardSpacing, int(math.Ceil(maxY-minY))+2*cardSpacing))
+	for i, img := range images {
+		drawRotated(canvas, img, centers[i][0]+offsetX, centers[i][1]+offsetY, angles[i])
+	}
+	return canvas
+}
+
+// drawRotated draws img over the canvas, turned by angle radians clockwise around its center, which lands at (cx, cy)
+func drawRotated(canvas *image.RGBA, img *image.RGBA, cx float64, cy float64, angle float64) {
+	sin, cos := math.Sincos(angle)
+	halfW, halfH := float64(img.Bounds().Dx())/2, float64(img.Bounds().Dy())/2
+	reach := math.Ceil(math.Hypot(halfW, halfH))
+	bounds := image.Rect(int(cx-reach), int(cy-reach), int(cx+reach)+1, int(cy+reach)+1).Intersect(canvas.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Turn the canvas pixel back into the card's own coordinates
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			sx := dx*cos + dy*sin + halfW - 0.5
+			sy := -dx*sin + dy*cos + halfH - 0.5
+			if sx < -1 || sy < -1 || sx > 2*halfW || sy > 2*halfH {
+				continue
+			}
+			blendPixel(canvas, x, y, sampleBilinear(img, sx, sy))
+		}
+	}
+}
+
+// sampleBilinear returns the premultiplied color of img at a point between pixels
+func sampleBilinear(img *image.RGBA, x float64, y float64) [4]float64 {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	var color [4]float64
+	for _, p := range []struct {
+		x, y   int
+		weight float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		if !(image.Point{p.x, p.y}).In(img.Bounds()) {
+			continue
+		}
+		offset := img.PixOffset(p.x, p.y)
+		for c := 0; c < 4; c++ {
+			color[c] += float64(img.Pix[offset+c]) * p.weight
+		}
+	}
+	return color
+}
+
+// blendPixel draws a premultiplied color over a canvas pixel
+func blendPixel(canvas *image.RGBA, x int, y int, color [4]float64) {
+	if color[3] <= 0 {
+		return
+	}
+	offset := canvas.PixOffset(x, y)
+	remaining := 1 - color[3]/255
+	for c := 0; c < 4; c++ {
+		canvas.Pix[offset+c] = uint8(math.Min(255, color[c]+float64(canvas.Pix[offset+c])*remaining+0.5))
+	}
+}
+
+// cardCodes joins the short notation of several cards with "-", e.g. "AS-10H-RJ"
+func cardCodes(cards []playingcards.Card) string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Short()
+	}
+	return strings.Join(codes, "-")
+}
+
+// Names of the card styles used in hand image URLs
+var styleNames = map[int]string{
+	KenneyLarge: "normal",
+	KenneyPixel: "pixel",
+}
+
+// imageKey signs the paths of rendered images, so the HTTP server only renders the images the bot linked to.
+// It is read from IMAGE_KEY, or made up when the bot starts, in which case links from before a restart stop working.
+var imageKey = loadImageKey()
+
+func loadImageKey() []byte {
+	if key := os.Getenv("IMAGE_KEY"); len(key) > 0 {
+		return []byte(key)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalln("Error making a key to sign image links,", err)
+	}
+	return key
+}
+
+// imageSignature returns the signature of the path of a rendered image
+func imageSignature(path string) string {
+	mac := hmac.New(sha256.New, imageKey)
+	mac.Write([]byte(path))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// signedImageURL returns the full url to a rendered image, signed so the server agrees to render it
+func signedImageURL(path string) string {
+	return fmt.Sprintf("%s%s?sig=%s", hostURL(), path, imageSignature(path))
+}
+
+// validImageRequest returns whether the request is for a path signed by signedImageURL
+func validImageRequest(r *http.Request) bool {
+	return hmac.Equal([]byte(r.URL.Query().Get("sig")), []byte(imageSignature(r.URL.Path)))
+}
+
+// GetHandURL returns the full url to an image of several cards in the given style and layout
+func GetHandURL(cards []playingcards.Card, style int, layout string) string {
+	return signedImageURL(fmt.Sprintf("/hands/%s/%s/%s.png", styleNames[style], layout, cardCodes(cards)))
+}
+
+// handImageHandler serves the images made by GetHandURL, at /hands/<style>/<layout>/<cards>.png
+func handImageHandler(w http.ResponseWriter, r *http.Request) {
+	if !validImageRequest(r) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/hands/"), "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ".png") {
+		http.NotFound(w, r)
+		return
+	}
+	style := -1
+	for s, name := range styleNames {
+		if name == parts[0] {
+			style = s
+		}
+	}
+	if style < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	codes := strings.Split(strings.TrimSuffix(parts[2], ".png"), "-")
+	cards := make([]playingcards.Card, len(codes))
+	for i, code := range codes {
+		card, err := playingcards.ParseCard(code)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cards[i] = card
+	}
+
+	data, err := renderer.Render(cards, style, parts[1])
+	if err != nil {
+		log.Println("Error rendering hand image,", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(data)
+}
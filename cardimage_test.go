@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func TestImageLinksAreSigned(t *testing.T) {
+	cards, _ := playingcards.ParseCards("AS 10H KD")
+	link := GetHandURL(cards, KenneyLarge, LayoutRow)
+	path := strings.TrimPrefix(link, hostURL())
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path, http.StatusOK},
+		{strings.Split(path, "?")[0], http.StatusForbidden},
+		{strings.Replace(path, "AS-10H-KD", "AS-10H-KH", 1), http.StatusForbidden},
+		{strings.Replace(path, "/row/", "/fan/", 1), http.StatusForbidden},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handImageHandler(recorder, httptest.NewRequest("GET", test.path, nil))
+		if recorder.Code != test.want {
+			t.Errorf("GET %s: status %d, want %d", test.path, recorder.Code, test.want)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	klondikeImageHandler(recorder, httptest.NewRequest("GET", "/klondike/normal/anything.png", nil))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("an unsigned Klondike board got status %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+}
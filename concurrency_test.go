@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestConcurrentDeckCommands draws, shuffles and resets one channel's deck from many goroutines at once.
+// Run it with -race to catch unlocked access to the server state.
+func TestConcurrentDeckCommands(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("race-deck"), "table"
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(int64(worker)))
+			userID := fmt.Sprintf("user%d", worker)
+			for n := 0; n < 40; n++ {
+				switch random.Intn(6) {
+				case 0:
+					f.Command(guildID, channelID, userID, "shuffle")
+				case 1:
+					f.Command(guildID, channelID, userID, "reset-cards")
+				case 2:
+					f.Command(guildID, channelID, userID, "deck-status")
+				case 3:
+					f.Command(guildID, channelID, userID, "peek", intOption("count", 1+random.Intn(3)))
+				default:
+					f.Command(guildID, channelID, userID, "draw", intOption("count", 1+random.Intn(5)))
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	deck := state.Channel(channelID).deck
+	if total := deck.Size() + len(deck.Drawn()); total != 52 {
+		t.Errorf("%d cards left and %d drawn, want 52 in total", deck.Size(), len(deck.Drawn()))
+	}
+}
+
+// TestConcurrentGameButtons presses the buttons of games in several channels of one guild at once,
+// while other players use the deck commands and stop the games
+func TestConcurrentGameButtons(t *testing.T) {
+	f := NewFakeSession()
+	guildID := testGuild("race-games")
+	players := []string{"alice", "bob", "carol", "dave"}
+
+	// Open the lobbies first, so every player has the same messages to press
+	lobbies := map[string]*discordgo.Message{}
+	for _, game := range []string{"holdem", "crazy-eights", "gofish", "hearts"} {
+		i := f.Command(guildID, game, players[0], game)
+		lobby, err := f.InteractionResponse(i.Interaction)
+		if err != nil {
+			t.Fatalf("/%s: %v", game, err)
+		}
+		lobbies[game] = lobby
+	}
+	prefixes := map[string]string{"holdem": "holdem", "crazy-eights": "crazy8", "gofish": "gofish", "hearts": "hearts"}
+
+	var wg sync.WaitGroup
+	for _, userID := range players {
+		for game, lobby := range lobbies {
+			wg.Add(1)
+			go func(userID string, game string, lobby *discordgo.Message) {
+				defer wg.Done()
+				prefix := prefixes[game]
+				f.Press(guildID, userID, lobby, prefix+":join")
+				f.Press(guildID, userID, lobby, prefix+":start")
+				for n := 0; n < 20; n++ {
+					messages := f.Messages(game)
+					table := messages[len(messages)-1]
+					for _, customID := range []string{"hand", "cards", "check", "call", "fold", "draw", "pass"} {
+						f.Press(guildID, userID, table, prefix+":"+customID)
+					}
+					f.Command(guildID, game, userID, "draw")
+				}
+			}(userID, game, lobby)
+		}
+	}
+	for game := range lobbies {
+		wg.Add(1)
+		go func(game string) {
+			defer wg.Done()
+			f.Command(guildID, game, "eve", "shuffle")
+			f.Command(guildID, game, "eve", "quit-game")
+			f.Command(guildID, game, "eve", "draw", intOption("count", 3))
+		}(game)
+	}
+	wg.Wait()
+
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for game := range lobbies {
+		if gameType := state.Channel(game).GameType(); gameType != NoGame {
+			t.Errorf("%s is still running as game %d after /quit-game", game, gameType)
+		}
+	}
+}
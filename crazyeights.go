@@ -0,0 +1,842 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: CrazyEights,
+		Name: "Crazy Eights",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "crazy-eights",
+			Description: "Start a game of Crazy Eights.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "draw",
+					Description: "How many cards a player draws when they can't or won't play (default one)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "One card", Value: "one"},
+						{Name: "Until a card can be played", Value: "until-playable"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "cards",
+					Description: "Cards dealt to each player (default 7 for two players, 5 for more)",
+					MinValue:    &minCrazyEightsCardsOptionValue,
+					MaxValue:    maxCrazyEightsCardsOptionValue,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "action-cards",
+					Description: "2s make the next player draw two and queens skip them (default false)",
+				},
+			},
+		},
+		CommandHandler:   crazyEightsCommand,
+		ComponentPrefix:  "crazy8",
+		ComponentHandler: crazyEightsComponent,
+	})
+}
+
+// Limits for games of Crazy Eights
+const (
+	MaxCrazyEightsPlayers  = 6
+	crazyEightsTurnTimeout = 60 * time.Second
+	crazyEightsLogLength   = 6
+)
+
+var (
+	minCrazyEightsCardsOptionValue = 3.0
+	maxCrazyEightsCardsOptionValue = 10.0
+)
+
+type crazyEightsPlayer struct {
+	userID string
+	hand   playingcards.Hand
+}
+
+// CrazyEightsGame holds the state of a game of Crazy Eights
+type CrazyEightsGame struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+	// Rules chosen when the game was made
+	drawUntilPlayable bool
+	startingCards     int
+	actionCards       bool
+
+	deck    *playingcards.Deck
+	discard playingcards.Pile
+	// suit is the suit to follow, which is the top card's suit unless an eight called another one
+	suit      playingcards.Suit
+	players   []*crazyEightsPlayer
+	started   bool
+	over      bool
+	closed    bool
+	turn      int
+	turnToken int
+	// drew is whether the current player already drew this turn
+	drew bool
+	// passes counts the turns in a row that ended without a card being played or drawn
+	passes int
+	// idleTurns counts the turns in a row that timed out
+	idleTurns int
+	log       []string
+}
+
+// NewCrazyEightsGame creates a game of Crazy Eights that players can join. The stock is deck, which the game owns
+// until it is over. A startingCards of 0 deals 7 cards for two players and 5 for more.
+func NewCrazyEightsGame(guildID string, channelID string, cardsStyle int, deck *playingcards.Deck, drawUntilPlayable bool, startingCards int, actionCards bool) *CrazyEightsGame {
+	return &CrazyEightsGame{
+		guildID:           guildID,
+		channelID:         channelID,
+		cardsStyle:        cardsStyle,
+		deck:              deck,
+		drawUntilPlayable: drawUntilPlayable,
+		startingCards:     startingCards,
+		actionCards:       actionCards,
+	}
+}
+
+// Lock locks the game's state
+func (g *CrazyEightsGame) Lock() {
+	g.mu.Lock()
+}
+
+// Unlock unlocks the game's state
+func (g *CrazyEightsGame) Unlock() {
+	g.mu.Unlock()
+}
+
+// End stops the game, so pending timers and button presses are ignored
+func (g *CrazyEightsGame) End() {
+	g.closed = true
+}
+
+func (g *CrazyEightsGame) addLog(format string, a ...interface{}) {
+	g.log = append(g.log, fmt.Sprintf(format, a...))
+	if len(g.log) > crazyEightsLogLength {
+		g.log = g.log[len(g.log)-crazyEightsLogLength:]
+	}
+}
+
+func (g *CrazyEightsGame) player(userID string) *crazyEightsPlayer {
+	for _, player := range g.players {
+		if player.userID == userID {
+			return player
+		}
+	}
+	return nil
+}
+
+// Join adds a player before the game starts
+func (g *CrazyEightsGame) Join(userID string) error {
+	if g.started {
+		return errors.New("The game has already started.")
+	}
+	if g.player(userID) != nil {
+		return errors.New("You already joined the game.")
+	}
+	if len(g.players) >= MaxCrazyEightsPlayers {
+		return errors.New("The game is full.")
+	}
+	g.players = append(g.players, &crazyEightsPlayer{userID: userID})
+	return nil
+}
+
+// Start shuffles the deck, deals the hands and turns up the first card of the discard pile
+func (g *CrazyEightsGame) Start(userID string) (bool, error) {
+	if g.started {
+		return false, errors.New("The game has already started.")
+	}
+	if g.player(userID) == nil {
+		return false, errors.New("Only players in the game can start it.")
+	}
+	if len(g.players) < 2 {
+		return false, errors.New("Crazy Eights needs at least two players.")
+	}
+	handSize := g.startingCards
+	if handSize == 0 {
+		handSize = 7
+		if len(g.players) > 2 {
+			handSize = 5
+		}
+	}
+	// Leave enough cards in the stock to play with
+	if handSize*len(g.players) > 40 {
+		return false, fmt.Errorf("There aren't enough cards to deal %d cards to %d players.", handSize, len(g.players))
+	}
+	g.deck.Shuffle()
+	for round := 0; round < handSize; round++ {
+		for _, player := range g.players {
+			player.hand.Add(g.deck.DrawCard())
+		}
+	}
+	// An eight can't start the discard pile, so it goes back into the middle of the deck
+	starter := g.deck.DrawCard()
+	for starter.Value() == 8 {
+		g.deck.Insert(starter, g.deck.Size()/2)
+		starter = g.deck.DrawCard()
+	}
+	g.discard.Put(starter)
+	g.suit = starter.Suit()
+	g.started = true
+	g.addLog("Dealt %d cards to each player. The first card is the %s.", handSize, starter.String())
+	return false, nil
+}
+
+// top returns the top card of the discard pile
+func (g *CrazyEightsGame) top() playingcards.Card {
+	card, _ := g.discard.Top()
+	return card
+}
+
+// canPlay returns whether the card can go on the discard pile: eights always can,
+// other cards must match the suit to follow or the top card's rank
+func (g *CrazyEightsGame) canPlay(card playingcards.Card) bool {
+	return card.Value() == 8 || card.Suit() == g.suit || card.Value() == g.top().Value()
+}
+
+// legalPlays returns the cards of the player's hand that can be played
+func (g *CrazyEightsGame) legalPlays(player *crazyEightsPlayer) []playingcards.Card {
+	plays := []playingcards.Card{}
+	for _, card := range player.hand.Cards() {
+		if g.canPlay(card) {
+			plays = append(plays, card)
+		}
+	}
+	return plays
+}
+
+// canDraw returns whether there is a card left to draw, counting the discard pile under the top card
+func (g *CrazyEightsGame) canDraw() bool {
+	return g.deck.Size()+g.discard.Size() > 1
+}
+
+// drawCard draws a card from the stock into the player's hand. When the stock runs out,
+// the discard pile except its top card is shuffled to make a new one.
+func (g *CrazyEightsGame) drawCard(player *crazyEightsPlayer) (playingcards.Card, bool) {
+	if g.deck.Size() == 0 {
+		if !g.canDraw() {
+			return playingcards.EmptyCard, false
+		}
+		cards := g.discard.Clear()
+		g.discard.Put(cards[len(cards)-1])
+		g.deck.AddToBottom(cards[:len(cards)-1]...)
+		g.deck.Shuffle()
+		g.addLog("The discard pile was shuffled into a new stock.")
+	}
+	card, err := g.deck.Draw()
+	if err != nil {
+		return playingcards.EmptyCard, false
+	}
+	player.hand.Add(card)
+	return card, true
+}
+
+// Action applies the current player's move: "draw", "pass", or "play:" followed by the card in short notation.
+// When playing an eight, amount is the suit it calls. It returns true once the game is over.
+func (g *CrazyEightsGame) Action(userID string, action string, amount int) (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	player := g.players[g.turn]
+	if player.userID != userID {
+		return false, errors.New("It's not your turn.")
+	}
+	var err error
+	switch {
+	case action == "draw":
+		err = g.draw(player)
+	case action == "pass":
+		err = g.pass(player)
+	case strings.HasPrefix(action, "play:"):
+		var card playingcards.Card
+		card, err = playingcards.ParseCard(strings.TrimPrefix(action, "play:"))
+		if err == nil {
+			err = g.play(player, card, playingcards.Suit(amount))
+		}
+	default:
+		err = errors.New("Unknown action.")
+	}
+	if err != nil {
+		return false, err
+	}
+	g.idleTurns = 0
+	return g.over, nil
+}
+
+// Timeout moves for the player who ran out of time: they play their first legal card, or else draw and pass.
+// The game ends if every player let their turn time out twice in a row. It returns true once the game is over.
+func (g *CrazyEightsGame) Timeout() (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	g.idleTurns++
+	if g.idleTurns >= 2*len(g.players) {
+		g.addLog("Nobody has played for a while, so the game is over.")
+		g.over = true
+		g.turnToken++
+		return true, nil
+	}
+	player := g.players[g.turn]
+	g.addLog("%s took too long, so they play automatically.", mention(player.userID))
+	if !g.drew && len(g.legalPlays(player)) == 0 && g.canDraw() {
+		g.draw(player)
+		if g.players[g.turn] != player {
+			return g.over, nil
+		}
+	}
+	if plays := g.legalPlays(player); len(plays) > 0 {
+		// Keep eights for last, since they can always be played
+		card := plays[0]
+		for _, play := range plays {
+			if play.Value() != 8 {
+				card = play
+				break
+			}
+		}
+		g.play(player, card, g.favoriteSuit(player, card))
+		return g.over, nil
+	}
+	g.pass(player)
+	return g.over, nil
+}
+
+// favoriteSuit returns the suit the player has the most cards of, not counting the given card
+func (g *CrazyEightsGame) favoriteSuit(player *crazyEightsPlayer, except playingcards.Card) playingcards.Suit {
+	counts := make(map[playingcards.Suit]int)
+	best := except.Suit()
+	for _, card := range player.hand.Cards() {
+		if card == except {
+			continue
+		}
+		counts[card.Suit()]++
+		if counts[card.Suit()] > counts[best] {
+			best = card.Suit()
+		}
+	}
+	return best
+}
+
+// play puts a card from the player's hand onto the discard pile and applies its effect
+func (g *CrazyEightsGame) play(player *crazyEightsPlayer, card playingcards.Card, called playingcards.Suit) error {
+	index := -1
+	for n, held := range player.hand.Cards() {
+		if held.SameFace(card) {
+			index = n
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("You don't have the %s.", card.String())
+	}
+	if !g.canPlay(card) {
+		return fmt.Errorf("The %s can't be played on the %s, play an eight or a card matching the suit or rank.", card.String(), g.top().String())
+	}
+	if card.Value() == 8 && (called < playingcards.CLUBS || called > playingcards.SPADES) {
+		return errors.New("Pick the suit the eight calls.")
+	}
+	card, _ = player.hand.RemoveAt(index)
+	g.discard.Put(card)
+	g.suit = card.Suit()
+	g.passes = 0
+	if card.Value() == 8 {
+		g.suit = called
+		g.addLog("%s played the %s and called **%s**.", mention(player.userID), card.String(), called.String())
+	} else {
+		g.addLog("%s played the %s.", mention(player.userID), card.String())
+	}
+	if player.hand.Size() == 0 {
+		g.over = true
+		g.turnToken++
+		return nil
+	}
+	if player.hand.Size() == 1 {
+		g.addLog("%s has one card left!", mention(player.userID))
+	}
+
+	next := (g.turn + 1) % len(g.players)
+	skip := false
+	if g.actionCards && card.Value() == 2 {
+		drawn := 0
+		for k := 0; k < 2; k++ {
+			if _, ok := g.drawCard(g.players[next]); ok {
+				drawn++
+			}
+		}
+		g.addLog("%s draws %d cards and misses their turn.", mention(g.players[next].userID), drawn)
+		skip = true
+	} else if g.actionCards && card.Value() == 12 {
+		g.addLog("%s is skipped.", mention(g.players[next].userID))
+		skip = true
+	}
+	if skip {
+		next = (next + 1) % len(g.players)
+	}
+	g.nextTurn(next)
+	return nil
+}
+
+// draw draws one card for the player, or with the draw until playable rule, cards until one can be played.
+// The turn passes if the player still has nothing to play.
+func (g *CrazyEightsGame) draw(player *crazyEightsPlayer) error {
+	if g.drew {
+		return errors.New("You already drew this turn. Play a card or pass.")
+	}
+	if !g.canDraw() {
+		return errors.New("There are no cards left to draw, so you have to play or pass.")
+	}
+	drawn := 0
+	playable := false
+	for !playable {
+		card, ok := g.drawCard(player)
+		if !ok {
+			break
+		}
+		drawn++
+		playable = g.canPlay(card)
+		if !g.drawUntilPlayable {
+			break
+		}
+	}
+	g.drew = true
+	cardString := "cards"
+	if drawn == 1 {
+		cardString = "card"
+	}
+	if playable {
+		g.addLog("%s drew %d %s.", mention(player.userID), drawn, cardString)
+		g.turnToken++
+		return nil
+	}
+	g.addLog("%s drew %d %s and can't play.", mention(player.userID), drawn, cardString)
+	g.nextTurn((g.turn + 1) % len(g.players))
+	return nil
+}
+
+// pass ends the player's turn without playing. They must draw first if there are cards left to draw.
+// The game is over once every player passed in a row with nothing to draw.
+func (g *CrazyEightsGame) pass(player *crazyEightsPlayer) error {
+	if !g.drew && g.canDraw() {
+		return errors.New("You have to draw before passing.")
+	}
+	if !g.drew {
+		g.passes++
+	}
+	g.addLog("%s passed.", mention(player.userID))
+	if g.passes >= len(g.players) {
+		g.addLog("Nobody can play anymore.")
+		g.over = true
+		g.turnToken++
+		return nil
+	}
+	g.nextTurn((g.turn + 1) % len(g.players))
+	return nil
+}
+
+func (g *CrazyEightsGame) nextTurn(turn int) {
+	g.turn = turn
+	g.drew = false
+	g.turnToken++
+}
+
+// winners returns the players with the fewest cards left. Once the game is over, that is the player who went out,
+// unless nobody could play anymore.
+func (g *CrazyEightsGame) winners() []*crazyEightsPlayer {
+	winners := []*crazyEightsPlayer{}
+	for _, player := range g.players {
+		if len(winners) == 0 || player.hand.Size() < winners[0].hand.Size() {
+			winners = []*crazyEightsPlayer{player}
+		} else if player.hand.Size() == winners[0].hand.Size() {
+			winners = append(winners, player)
+		}
+	}
+	return winners
+}
+
+// Discord side of the game
+
+// crazyEightsCommand handles the /crazy-eights slash command by opening a game for players to join
+func crazyEightsCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	drawUntilPlayable, startingCards, actionCards := false, 0, false
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "draw":
+			drawUntilPlayable = opt.StringValue() == "until-playable"
+		case "cards":
+			startingCards = int(opt.IntValue())
+		case "action-cards":
+			actionCards = opt.BoolValue()
+		}
+	}
+
+	game := NewCrazyEightsGame(i.GuildID, i.ChannelID, state.cardsStyle, channel.setStandardDeck(), drawUntilPlayable, startingCards, actionCards)
+	game.Join(interactionUserID(i))
+	channel.game = GameState{gameType: CrazyEights, current: game}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+			Components: crazyEightsLobbyComponents(),
+		},
+	})
+}
+
+// rulesText summarizes the rules chosen for the game
+func (g *CrazyEightsGame) rulesText() string {
+	rules := []string{"Draw one card"}
+	if g.drawUntilPlayable {
+		rules[0] = "Draw until playable"
+	}
+	if g.startingCards > 0 {
+		rules = append(rules, fmt.Sprintf("%d cards each", g.startingCards))
+	}
+	if g.actionCards {
+		rules = append(rules, "2s draw two, queens skip")
+	}
+	return strings.Join(rules, " • ")
+}
+
+func (g *CrazyEightsGame) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	for _, player := range g.players {
+		players.WriteString(fmt.Sprintf("%s\n", mention(player.userID)))
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "Crazy Eights",
+		Description: "Press **Join** to play, then any player can press **Start** to deal.\nPlay a card matching the suit or rank of the top card, or an eight to call a new suit. The first player out of cards wins!",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", len(g.players), MaxCrazyEightsPlayers),
+				Value: players.String(),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: g.rulesText(),
+		},
+	}
+}
+
+func crazyEightsLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "crazy8:join"},
+				discordgo.Button{Label: "Start", Style: discordgo.PrimaryButton, CustomID: "crazy8:start"},
+			},
+		},
+	}
+}
+
+// crazyEightsComponent handles the buttons and menus of a game of Crazy Eights
+func crazyEightsComponent(s Session, i *discordgo.InteractionCreate) {
+	game, _ := runningGame(i, CrazyEights).(*CrazyEightsGame)
+	if game == nil {
+		respondEphemeral(s, i, "This game of Crazy Eights is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if game.closed {
+		respondEphemeral(s, i, "This game of Crazy Eights is no longer running.")
+		return
+	}
+
+	data := i.MessageComponentData()
+	switch {
+	case data.CustomID == "crazy8:join":
+		if err := game.Join(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: crazyEightsLobbyComponents(),
+			},
+		})
+	case data.CustomID == "crazy8:start":
+		if _, err := game.Start(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		embed, components := game.render()
+		message, err := s.ChannelMessageSendComplex(game.channelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		})
+		if err != nil {
+			s.ChannelMessageSend(game.channelID, "Error found while running the game. Exiting...")
+			game.end()
+			return
+		}
+		game.messageID = message.ID
+		game.scheduleTimeout(s)
+	case data.CustomID == "crazy8:hand":
+		if game.player(userID) == nil {
+			respondEphemeral(s, i, "You are not playing in this game.")
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: game.handPanel(userID, ""),
+		})
+	case data.CustomID == "crazy8:play":
+		if len(data.Values) == 0 {
+			respondEphemeral(s, i, "Pick a card to play.")
+			return
+		}
+		card, err := playingcards.ParseCard(data.Values[0])
+		if err == nil && card.Value() == 8 && game.players[game.turn].userID == userID {
+			// Ask which suit the eight calls before playing it
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: game.handPanel(userID, data.Values[0]),
+			})
+			return
+		}
+		game.handleAction(s, i, userID, "play:"+data.Values[0], 0)
+	case strings.HasPrefix(data.CustomID, "crazy8:suit:"):
+		if len(data.Values) == 0 {
+			respondEphemeral(s, i, "Pick the suit the eight calls.")
+			return
+		}
+		suit, err := strconv.Atoi(data.Values[0])
+		if err != nil {
+			respondEphemeral(s, i, "Pick the suit the eight calls.")
+			return
+		}
+		game.handleAction(s, i, userID, "play:"+strings.TrimPrefix(data.CustomID, "crazy8:suit:"), suit)
+	case data.CustomID == "crazy8:draw", data.CustomID == "crazy8:pass":
+		game.handleAction(s, i, userID, strings.TrimPrefix(data.CustomID, "crazy8:"), 0)
+	}
+}
+
+// handleAction applies a player's move from their hand panel, then refreshes the panel and the game message.
+// The game must be locked.
+func (g *CrazyEightsGame) handleAction(s Session, i *discordgo.InteractionCreate, userID string, action string, amount int) {
+	over, err := g.Action(userID, action, amount)
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: g.handPanel(userID, ""),
+	})
+	g.afterTurn(s, over)
+}
+
+// afterTurn refreshes the game message, and either waits for the next move or ends the game. The game must be locked.
+func (g *CrazyEightsGame) afterTurn(s Session, over bool) {
+	g.updateMessage(s)
+	if over {
+		g.end()
+		return
+	}
+	g.scheduleTimeout(s)
+}
+
+// end stops the game and resets its channel. The game must be locked.
+func (g *CrazyEightsGame) end() {
+	g.End()
+	endGame(g.guildID, g.channelID, g)
+}
+
+// scheduleTimeout moves for the current player if they take too long. The game must be locked.
+func (g *CrazyEightsGame) scheduleTimeout(s Session) {
+	token := g.turnToken
+	time.AfterFunc(crazyEightsTurnTimeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed || g.turnToken != token {
+			return
+		}
+		over, err := g.Timeout()
+		if err != nil {
+			return
+		}
+		g.afterTurn(s, over)
+	})
+}
+
+// updateMessage edits the game message to show the current state of the game. The game must be locked.
+func (g *CrazyEightsGame) updateMessage(s Session) {
+	embed, components := g.render()
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         g.messageID,
+		Channel:    g.channelID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
+
+// render builds the public game message: the last moves, the players' card counts, and the top card of the discard pile
+func (g *CrazyEightsGame) render() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	top := g.top()
+	var description strings.Builder
+	description.WriteString(strings.Join(g.log, "\n"))
+	description.WriteString("\n\n")
+	for n, player := range g.players {
+		marker := "▫️"
+		if !g.over && n == g.turn {
+			marker = "▶️"
+		}
+		description.WriteString(fmt.Sprintf("%s %s: %d cards\n", marker, mention(player.userID), player.hand.Size()))
+	}
+	if g.over {
+		winners := g.winners()
+		mentions := make([]string, len(winners))
+		for n, player := range winners {
+			mentions[n] = mention(player.userID)
+		}
+		if len(winners) == 1 {
+			description.WriteString(fmt.Sprintf("\n**%s wins the game!**", mentions[0]))
+		} else {
+			description.WriteString(fmt.Sprintf("\n**It's a tie between %s!**", strings.Join(mentions, " and ")))
+		}
+	} else {
+		if g.suit != top.Suit() {
+			description.WriteString(fmt.Sprintf("\nThe eight called **%s**.", g.suit.String()))
+		}
+		description.WriteString(fmt.Sprintf("\n%s to play. Press **My hand** to see your cards and play.", mention(g.players[g.turn].userID)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Crazy Eights: %s", top.String()),
+		Description: description.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s • %d cards in the stock", g.rulesText(), g.deck.Size()),
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: GetCardURL(top, g.cardsStyle),
+		},
+	}
+	if g.over {
+		return embed, []discordgo.MessageComponent{}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "My hand", Style: discordgo.SecondaryButton, CustomID: "crazy8:hand"},
+			},
+		},
+	}
+}
+
+// handPanel returns the message showing a player their hand. On their turn, it also has the menu of cards
+// they can play and the Draw and Pass buttons. With an eight picked, it asks which suit to call instead.
+func (g *CrazyEightsGame) handPanel(userID string, eight string) *discordgo.InteractionResponseData {
+	player := g.player(userID)
+	player.hand.SortBySuit(playingcards.AceHigh)
+	cards := player.hand.Cards()
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Your hand (%d cards)", len(cards)),
+		Description: cardNames(cards),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Top card: %s", g.top().String()),
+		},
+	}
+	if len(cards) == 0 {
+		embed.Description = "You have no cards."
+	} else {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(cards, g.cardsStyle, LayoutFan)}
+	}
+	if g.suit != g.top().Suit() {
+		embed.Footer.Text += fmt.Sprintf(", the eight called %s", g.suit.String())
+	}
+	data := &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{},
+		Flags:      discordgo.MessageFlagsEphemeral,
+	}
+	if g.over {
+		return data
+	}
+	if g.players[g.turn].userID != userID {
+		data.Content = fmt.Sprintf("It's %s's turn. Press **My hand** again to refresh your cards.", mention(g.players[g.turn].userID))
+		return data
+	}
+
+	if eight != "" {
+		suits := []discordgo.SelectMenuOption{}
+		for suit := playingcards.CLUBS; suit <= playingcards.SPADES; suit++ {
+			suits = append(suits, discordgo.SelectMenuOption{Label: suit.String(), Value: strconv.Itoa(int(suit))})
+		}
+		data.Content = "Which suit does your eight call?"
+		data.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{CustomID: "crazy8:suit:" + eight, Placeholder: "Suit to call", Options: suits},
+				},
+			},
+		}
+		return data
+	}
+
+	data.Content = "It's your turn! Play a card, or draw."
+	if plays := g.legalPlays(player); len(plays) > 0 {
+		options := make([]discordgo.SelectMenuOption, len(plays))
+		for n, card := range plays {
+			options[n] = discordgo.SelectMenuOption{Label: card.String(), Value: card.Short()}
+		}
+		data.Components = append(data.Components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "crazy8:play", Placeholder: "Card to play", Options: options},
+			},
+		})
+	} else {
+		data.Content = "It's your turn! You have no card to play, so draw."
+	}
+	canPass := g.drew || !g.canDraw()
+	data.Components = append(data.Components, discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Draw", Style: discordgo.PrimaryButton, CustomID: "crazy8:draw", Disabled: canPass},
+			discordgo.Button{Label: "Pass", Style: discordgo.SecondaryButton, CustomID: "crazy8:pass", Disabled: !canPass},
+		},
+	})
+	return data
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+// TestCrazyEightsMenus plays a card or draws in Crazy Eights through the hand panel
+func TestCrazyEightsMenus(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("menus-crazy8"), "table"
+	lobby, _ := f.InteractionResponse(f.Command(guildID, channelID, "101", "crazy-eights").Interaction)
+	f.Press(guildID, "102", lobby, "crazy8:join")
+	f.Press(guildID, "101", lobby, "crazy8:start")
+	table := lastMessage(t, f, channelID)
+	before := messageText(table.Content, table.Embeds)
+
+	for _, userID := range []string{"101", "102"} {
+		panel := ephemeralMessage(t, f, f.Press(guildID, userID, table, "crazy8:hand"))
+		if !hasButton(panel, "crazy8:draw") {
+			continue
+		}
+		if play := selectMenu(panel, "crazy8:play"); play != nil {
+			panel = ephemeralMessage(t, f, f.Choose(guildID, userID, panel, "crazy8:play", play.Options[0].Value))
+			if suit := selectMenu(panel, "crazy8:suit:"); suit != nil {
+				f.Choose(guildID, userID, panel, suit.CustomID, suit.Options[0].Value)
+			}
+		} else {
+			f.Press(guildID, userID, panel, "crazy8:draw")
+		}
+		table = lastMessage(t, f, channelID)
+		if after := messageText(table.Content, table.Embeds); after == before {
+			t.Error("the table didn't change after the move")
+		}
+		f.Command(guildID, channelID, "101", "quit-game")
+		return
+	}
+	t.Error("neither player got the buttons to play")
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// MaxPeekCount is the most cards that can be looked at with a single /peek
+const MaxPeekCount = 10
+
+// cardNames lists the names of the given cards, e.g. "Ace of Spades, 10 of Hearts"
+func cardNames(cards []playingcards.Card) string {
+	names := make([]string, len(cards))
+	for n, card := range cards {
+		names[n] = card.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// discardCards moves a player's drawn cards to the channel's discard pile, all of them if cards is empty,
+// then shuffles the discard pile back into the deck if reshuffle is set.
+// It returns a status message in response. The state must be locked.
+func discardCards(state *ServerState, channel *ChannelState, userID string, cards string, reshuffle bool) string {
+	if channel.GameType() != NoGame {
+		return gameInProgressWarning()
+	}
+	playerZone := playingcards.PlayerZone(userID)
+	var msg strings.Builder
+	if cards != "" || !reshuffle {
+		toDiscard, err := playingcards.ParseCards(cards)
+		if err != nil {
+			return fmt.Sprintf("Couldn't read the cards to discard: %v. Write cards like `AS 10H QD`.", err)
+		}
+		if len(toDiscard) == 0 {
+			toDiscard = channel.zones.Zone(playerZone).Cards()
+			if len(toDiscard) == 0 {
+				return "You have no cards to discard. Cards you draw with /draw can be discarded."
+			}
+		}
+		discarded := []playingcards.Card{}
+		for _, card := range toDiscard {
+			moved, err := channel.zones.MoveCard(playerZone, playingcards.ZoneDiscard, card)
+			if err != nil {
+				// Put back the cards discarded so far, so nothing changes
+				for n := len(discarded) - 1; n >= 0; n-- {
+					channel.zones.MoveCard(playingcards.ZoneDiscard, playerZone, discarded[n])
+				}
+				return fmt.Sprintf("You don't have the %s.", card.String())
+			}
+			discarded = append(discarded, moved)
+		}
+		msg.WriteString(fmt.Sprintf("Discarded %s.", cardNames(discarded)))
+	}
+	if reshuffle {
+		if msg.Len() > 0 {
+			msg.WriteString(" ")
+		}
+		count := channel.zones.Reshuffle(&channel.deck, playingcards.ZoneDiscard)
+		msg.WriteString(fmt.Sprintf("Shuffled %d cards from the discard pile back into the deck.", count))
+	} else {
+		msg.WriteString(fmt.Sprintf(" The discard pile has %d cards.", channel.zones.Zone(playingcards.ZoneDiscard).Size()))
+	}
+	state.save()
+	return msg.String()
+}
+
+// peekResponse returns the message showing the top cards of the channel's deck without drawing them.
+// The state must be locked.
+func peekResponse(state *ServerState, channel *ChannelState, count int) *discordgo.InteractionResponseData {
+	if channel.GameType() != NoGame {
+		return &discordgo.InteractionResponseData{
+			Content: gameInProgressWarning(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	cards, err := channel.deck.Peek(count)
+	if err != nil {
+		return &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Can't peek at %d cards, only %d cards are left in the deck.", count, channel.deck.Size()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	title := "Top card of the deck"
+	imageURL := GetCardURL(cards[0], state.cardsStyle)
+	if count > 1 {
+		title = fmt.Sprintf("Top %d cards of the deck", count)
+		imageURL = GetHandURL(cards, state.cardsStyle, LayoutRow)
+	}
+	message := &discordgo.MessageEmbed{
+		Color:       0x7fb2f0,
+		Title:       title,
+		Description: cardNames(cards),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "The first card is on top. Only you can see this.",
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: imageURL,
+		},
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	}
+}
+
+// cutDeck cuts the channel's deck, taking position cards off the top and putting them underneath.
+// A position of 0 cuts somewhere around the middle of the deck. It returns a status message in response.
+// The state must be locked.
+func cutDeck(state *ServerState, channel *ChannelState, position int) string {
+	if channel.GameType() != NoGame {
+		return gameInProgressWarning()
+	}
+	size := channel.deck.Size()
+	if size < 2 {
+		return "There aren't enough cards left in the deck to cut it."
+	}
+	if position == 0 {
+		// Cut between a quarter and three quarters of the way down
+		position = size/4 + rand.Intn(size/2+1)
+		if position < 1 {
+			position = 1
+		} else if position >= size {
+			position = size - 1
+		}
+	}
+	if err := channel.deck.Cut(position); err != nil {
+		return fmt.Sprintf("The cut must leave cards on both sides, so it has to be between 1 and %d.", size-1)
+	}
+	state.save()
+	return fmt.Sprintf("Cut the deck, moving the top %d cards to the bottom.", position)
+}
+
+// isModerator returns whether the user who triggered the interaction can manage the server
+func isModerator(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// cardCodeFields splits a list of cards in short notation into embed fields, since a field holds at most 1024 characters
+func cardCodeFields(name string, cards []playingcards.Card) []*discordgo.MessageEmbedField {
+	const maxFieldLength = 1024
+	fields := []*discordgo.MessageEmbedField{}
+	var value strings.Builder
+	for _, card := range cards {
+		if value.Len()+len(card.Short())+1 > maxFieldLength {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value.String()})
+			value.Reset()
+		}
+		if value.Len() > 0 {
+			value.WriteString(" ")
+		}
+		value.WriteString(card.Short())
+	}
+	if value.Len() == 0 {
+		value.WriteString("None")
+	}
+	return append(fields, &discordgo.MessageEmbedField{Name: name, Value: value.String()})
+}
+
+// deckStatusResponse returns the message describing the channel's deck: what is left in it, what was drawn from it,
+// and the server's deck settings. With fullListing, the remaining cards are listed in order. The state must be locked.
+func deckStatusResponse(state *ServerState, channel *ChannelState, fullListing bool) *discordgo.InteractionResponseData {
+	if channel.GameType() != NoGame {
+		// The game may be dealing from the deck under its own lock
+		return &discordgo.InteractionResponseData{
+			Content: gameInProgressWarning(),
+		}
+	}
+	remaining := channel.deck.Cards()
+	suitCounts := make(map[playingcards.Suit]int)
+	valueCounts := make(map[int]int)
+	jokers := 0
+	for _, card := range remaining {
+		if card.Suit() == playingcards.RED_JOKER || card.Suit() == playingcards.BLACK_JOKER {
+			jokers++
+			continue
+		}
+		suitCounts[card.Suit()]++
+		valueCounts[card.Value()]++
+	}
+
+	var bySuit strings.Builder
+	for suit := playingcards.CLUBS; suit <= playingcards.SPADES; suit++ {
+		bySuit.WriteString(fmt.Sprintf("%s: %d\n", suit.String(), suitCounts[suit]))
+	}
+	if state.includeJokers || jokers > 0 {
+		bySuit.WriteString(fmt.Sprintf("Jokers: %d\n", jokers))
+	}
+	byRank := make([]string, 0, 13)
+	for value := 1; value <= 13; value++ {
+		rank := strings.TrimSuffix(playingcards.NewCard(value, playingcards.SPADES).Short(), "S")
+		byRank = append(byRank, fmt.Sprintf("%s: %d", rank, valueCounts[value]))
+	}
+
+	jokersSetting := "not included"
+	if state.includeJokers {
+		jokersSetting = "included"
+	}
+	settings := fmt.Sprintf("Style: %s\nDecks: %d\nJokers: %s", styleNames[state.cardsStyle], channel.deck.NumDecks(), jokersSetting)
+	if channel.deck.CutCardReached() {
+		settings += "\nThe cut card has been reached."
+	}
+
+	drawn := channel.deck.Drawn()
+	drawnValue := "None"
+	if len(drawn) > 0 {
+		codes := make([]string, len(drawn))
+		for n, card := range drawn {
+			codes[n] = card.Short()
+		}
+		drawnValue = strings.Join(codes, " ")
+		// Only keep the most recent cards if they don't fit in the field
+		for skipped := 0; len(drawnValue) > 1000; {
+			skipped++
+			drawnValue = fmt.Sprintf("(%d earlier) … %s", skipped, strings.Join(codes[skipped:], " "))
+		}
+	}
+
+	message := &discordgo.MessageEmbed{
+		Color: 0x607d8b,
+		Title: "Deck status",
+		Description: fmt.Sprintf("%d cards left in the deck, %d drawn, %d in the discard pile.",
+			len(remaining), len(drawn), channel.zones.Zone(playingcards.ZoneDiscard).Size()),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Left by suit", Value: bySuit.String(), Inline: true},
+			{Name: "Left by rank", Value: strings.Join(byRank, "\n"), Inline: true},
+			{Name: "Settings", Value: settings, Inline: true},
+			{Name: "Drawn, in order", Value: drawnValue},
+		},
+	}
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+	}
+	if fullListing {
+		// The order of the deck is secret, so only the moderator asking for it sees it
+		message.Fields = append(message.Fields, cardCodeFields("Left in the deck, from the top", remaining)...)
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	return data
+}
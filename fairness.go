@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// ShuffleProof holds what players need to check a provably fair shuffle once its game is over
+type ShuffleProof struct {
+	// SeedHash is the hash of the server seed that was posted before the game
+	SeedHash   string `json:"seedHash"`
+	ServerSeed string `json:"serverSeed"`
+	ClientSeed string `json:"clientSeed"`
+	// Cards are the cards dealt during the game, in order
+	Cards []playingcards.Card `json:"cards"`
+}
+
+// newFairDeck returns the deck provably fair games shuffle, so its order before the shuffle is always the same
+func newFairDeck() playingcards.Deck {
+	return newStandardDeck()
+}
+
+// fairShuffle shuffles a new fair deck with the given seeds
+func fairShuffle(serverSeed string, clientSeed string) playingcards.Deck {
+	deck := newFairDeck()
+	deck.ShuffleWithSeeds(serverSeed, clientSeed)
+	return deck
+}
+
+// Verify checks that the server seed matches the posted hash, and that shuffling with the seeds deals the same cards.
+// It returns a message explaining the result.
+func (p *ShuffleProof) Verify() (bool, string) {
+	if !playingcards.VerifySeed(p.ServerSeed, p.SeedHash) {
+		return false, fmt.Sprintf("The server seed does not match the hash `%s` posted before the game!", p.SeedHash)
+	}
+	deck := fairShuffle(p.ServerSeed, p.ClientSeed)
+	expected, err := deck.DrawCards(len(p.Cards))
+	if err != nil {
+		return false, "The game dealt more cards than the deck holds!"
+	}
+	for n, card := range p.Cards {
+		if card != expected[n] {
+			return false, fmt.Sprintf("Card %d of the game was %s, but the seeds deal %s!", n+1, card.String(), expected[n].String())
+		}
+	}
+	return true, fmt.Sprintf("The server seed matches the hash posted before the game, and shuffling with these seeds deals the same %d cards.", len(p.Cards))
+}
+
+// revealMessage returns the message that reveals the seeds of a finished provably fair game
+func (p *ShuffleProof) revealMessage() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Color:       0x607d8b,
+		Title:       "Provably fair shuffle",
+		Description: fmt.Sprintf("Hash posted before the game: `%s`\nServer seed: `%s`\nClient seed: `%s`", p.SeedHash, p.ServerSeed, p.ClientSeed),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /verify to check the cards that were dealt.",
+		},
+	}
+}
+
+// recordShuffleProof keeps the proof of a finished game, so /verify can check it later
+func recordShuffleProof(guildID string, channelID string, proof *ShuffleProof) {
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.Channel(channelID).lastShuffle = proof
+	state.save()
+}
+
+// verifyCommand handles the /verify slash command. Without options it checks the last provably fair game
+// of the channel, otherwise it shows the hash and deck order of the given seeds.
+func verifyCommand(s Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+	serverSeed, hasServerSeed := optionMap["server-seed"]
+	clientSeed, hasClientSeed := optionMap["client-seed"]
+
+	msg := ""
+	if hasServerSeed && hasClientSeed {
+		deck := fairShuffle(serverSeed.StringValue(), clientSeed.StringValue())
+		cards, _ := deck.DrawCards(deck.Size())
+		codes := make([]string, len(cards))
+		for n, card := range cards {
+			codes[n] = card.Short()
+		}
+		msg = fmt.Sprintf("Hash of the server seed: `%s`\nCards in the order they are dealt: %s",
+			playingcards.HashSeed(serverSeed.StringValue()), strings.Join(codes, " "))
+	} else if hasServerSeed || hasClientSeed {
+		msg = "Give both the server seed and the client seed, or neither to check the last game in this channel."
+	} else {
+		state := GetServerState(i.GuildID)
+		state.mu.Lock()
+		proof := state.Channel(i.ChannelID).lastShuffle
+		state.mu.Unlock()
+		if proof == nil {
+			msg = "No provably fair game has finished in this channel yet."
+		} else if ok, result := proof.Verify(); ok {
+			msg = "✅ " + result
+		} else {
+			msg = "❌ " + result
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+		},
+	})
+}
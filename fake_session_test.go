@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FakeSession is an in-memory Session that records the messages and interaction responses the bot sends,
+// and can feed the bot slash commands, button presses, select menu choices, modal submissions and text messages.
+type FakeSession struct {
+	mu     sync.Mutex
+	nextID int
+	// messages holds every message the bot sent, keyed by ID, with any edits applied
+	messages map[string]*discordgo.Message
+	// order lists the IDs of the messages in the order they were sent
+	order []string
+	// responses holds the responses to each interaction, keyed by interaction ID
+	responses map[string][]*discordgo.InteractionResponse
+	// responseMessages maps interactions to the message their response created
+	responseMessages map[string]string
+}
+
+// NewFakeSession creates a fake session with no messages
+func NewFakeSession() *FakeSession {
+	return &FakeSession{
+		messages:         make(map[string]*discordgo.Message),
+		responses:        make(map[string][]*discordgo.InteractionResponse),
+		responseMessages: make(map[string]string),
+	}
+}
+
+// newID returns a new unique snowflake-like ID. The session must be locked.
+func (f *FakeSession) newID() string {
+	f.nextID++
+	return fmt.Sprintf("%d", f.nextID)
+}
+
+// addMessage stores a new message sent by the bot. The session must be locked.
+func (f *FakeSession) addMessage(channelID string, content string, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) *discordgo.Message {
+	message := &discordgo.Message{
+		ID:         f.newID(),
+		ChannelID:  channelID,
+		Content:    content,
+		Embeds:     embeds,
+		Components: components,
+	}
+	f.messages[message.ID] = message
+	f.order = append(f.order, message.ID)
+	copied := *message
+	return &copied
+}
+
+// InteractionRespond records the response, and posts or edits the message it describes
+func (f *FakeSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[interaction.ID] = append(f.responses[interaction.ID], resp)
+	data := resp.Data
+	if data == nil {
+		return nil
+	}
+	switch resp.Type {
+	case discordgo.InteractionResponseChannelMessageWithSource:
+		if data.Flags&discordgo.MessageFlagsEphemeral != 0 {
+			// Ephemeral messages are only seen by the user, so they are only kept as responses
+			return nil
+		}
+		message := f.addMessage(interaction.ChannelID, data.Content, data.Embeds, data.Components)
+		f.responseMessages[interaction.ID] = message.ID
+	case discordgo.InteractionResponseUpdateMessage:
+		if interaction.Message == nil {
+			return errors.New("no message to update")
+		}
+		if interaction.Message.Flags&discordgo.MessageFlagsEphemeral != 0 {
+			// Ephemeral messages aren't kept, so their updates are only kept as responses
+			return nil
+		}
+		message, ok := f.messages[interaction.Message.ID]
+		if !ok {
+			return errors.New("unknown message")
+		}
+		message.Content = data.Content
+		if data.Embeds != nil {
+			message.Embeds = data.Embeds
+		}
+		if data.Components != nil {
+			message.Components = data.Components
+		}
+	}
+	return nil
+}
+
+// InteractionResponse returns the message created by the response to an interaction
+func (f *FakeSession) InteractionResponse(interaction *discordgo.Interaction) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, ok := f.responseMessages[interaction.ID]
+	if !ok {
+		return nil, errors.New("the interaction has no response message")
+	}
+	copied := *f.messages[id]
+	return &copied, nil
+}
+
+// ChannelMessageSend records a text message
+func (f *FakeSession) ChannelMessageSend(channelID string, content string) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addMessage(channelID, content, nil, nil), nil
+}
+
+// ChannelMessageSendEmbed records a message with a single embed
+func (f *FakeSession) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addMessage(channelID, "", []*discordgo.MessageEmbed{embed}, nil), nil
+}
+
+// ChannelMessageSendComplex records a message with embeds and components
+func (f *FakeSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addMessage(channelID, data.Content, data.Embeds, data.Components), nil
+}
+
+// ChannelMessageEditComplex applies an edit to a recorded message
+func (f *FakeSession) ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, ok := f.messages[m.ID]
+	if !ok || message.ChannelID != m.Channel {
+		return nil, errors.New("unknown message")
+	}
+	if m.Content != nil {
+		message.Content = *m.Content
+	}
+	if m.Embeds != nil {
+		message.Embeds = m.Embeds
+	}
+	if m.Components != nil {
+		message.Components = m.Components
+	}
+	copied := *message
+	return &copied, nil
+}
+
+// Messages returns the messages the bot sent to a channel, oldest first, as they currently look
+func (f *FakeSession) Messages(channelID string) []*discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := []*discordgo.Message{}
+	for _, id := range f.order {
+		if message := f.messages[id]; message.ChannelID == channelID {
+			copied := *message
+			messages = append(messages, &copied)
+		}
+	}
+	return messages
+}
+
+// Responses returns every response the bot gave to an interaction
+func (f *FakeSession) Responses(interaction *discordgo.InteractionCreate) []*discordgo.InteractionResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*discordgo.InteractionResponse{}, f.responses[interaction.ID]...)
+}
+
+// newInteraction creates an interaction from a user in a channel
+func (f *FakeSession) newInteraction(guildID string, channelID string, userID string, interactionType discordgo.InteractionType, data discordgo.InteractionData) *discordgo.InteractionCreate {
+	f.mu.Lock()
+	id := f.newID()
+	f.mu.Unlock()
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:        id,
+			Type:      interactionType,
+			Data:      data,
+			GuildID:   guildID,
+			ChannelID: channelID,
+			Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		},
+	}
+}
+
+// Command runs a slash command as the given user, and returns the interaction so its responses can be checked
+func (f *FakeSession) Command(guildID string, channelID string, userID string, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := f.newInteraction(guildID, channelID, userID, discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		Name:    name,
+		Options: options,
+	})
+	interactionCreate(f, i)
+	return i
+}
+
+// Press presses a button on a message the bot sent, as the given user
+func (f *FakeSession) Press(guildID string, userID string, message *discordgo.Message, customID string) *discordgo.InteractionCreate {
+	i := f.newInteraction(guildID, message.ChannelID, userID, discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{
+		CustomID:      customID,
+		ComponentType: discordgo.ButtonComponent,
+	})
+	i.Message = message
+	interactionCreate(f, i)
+	return i
+}
+
+// Choose picks values in a select menu on a message the bot sent, as the given user.
+// The message can be an ephemeral response, which the fake doesn't keep, as long as it has the channel ID.
+func (f *FakeSession) Choose(guildID string, userID string, message *discordgo.Message, customID string, values ...string) *discordgo.InteractionCreate {
+	i := f.newInteraction(guildID, message.ChannelID, userID, discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{
+		CustomID:      customID,
+		ComponentType: discordgo.SelectMenuComponent,
+		Values:        values,
+	})
+	i.Message = message
+	interactionCreate(f, i)
+	return i
+}
+
+// Submit submits a modal with the given text input values, keyed by their custom IDs, as the given user
+func (f *FakeSession) Submit(guildID string, channelID string, userID string, customID string, values map[string]string) *discordgo.InteractionCreate {
+	components := []discordgo.MessageComponent{}
+	for inputID, value := range values {
+		components = append(components, &discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				&discordgo.TextInput{CustomID: inputID, Value: value},
+			},
+		})
+	}
+	i := f.newInteraction(guildID, channelID, userID, discordgo.InteractionModalSubmit, discordgo.ModalSubmitInteractionData{
+		CustomID:   customID,
+		Components: components,
+	})
+	interactionCreate(f, i)
+	return i
+}
+
+// Say sends a text message to a channel as the given user
+func (f *FakeSession) Say(guildID string, channelID string, userID string, content string) {
+	f.mu.Lock()
+	id := f.newID()
+	f.mu.Unlock()
+	messageCreate(f, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        id,
+			GuildID:   guildID,
+			ChannelID: channelID,
+			Content:   content,
+			Author:    &discordgo.User{ID: userID},
+		},
+	})
+}
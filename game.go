@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Game is a card game running in a channel.
+// Its methods only change the game's own state, so a game can be driven without a Discord session;
+// the Discord side of each game calls them and then shows the result.
+// Every method must be called while holding the game's lock.
+type Game interface {
+	sync.Locker
+	// Join adds a player to the game
+	Join(userID string) error
+	// Start begins play once the players have joined. It returns true if the first round is already over.
+	Start(userID string) (bool, error)
+	// Action applies a player's move, named like the game's buttons. It returns true once the round is over.
+	Action(userID string, action string, amount int) (bool, error)
+	// Timeout makes the move of the player who ran out of time, or ends a timed round.
+	// It returns true once the round is over.
+	Timeout() (bool, error)
+	// End stops the game, so pending timers and button presses are ignored
+	End()
+}
+
+// GameInfo describes a game the bot can run, and how Discord interactions reach it
+type GameInfo struct {
+	Type int
+	Name string
+	// Command is the slash command that starts the game, handled by CommandHandler
+	Command        *discordgo.ApplicationCommand
+	CommandHandler func(s Session, i *discordgo.InteractionCreate)
+	// ComponentHandler handles the buttons and modals whose custom IDs start with ComponentPrefix and a ":"
+	ComponentPrefix  string
+	ComponentHandler func(s Session, i *discordgo.InteractionCreate)
+	// MessageHandler handles the "$pcb" text command MessageCommand, if the game has one
+	MessageCommand string
+	MessageHandler func(s Session, m *discordgo.MessageCreate)
+	// ExtraCommands are other slash commands used while playing the game, like making a move
+	ExtraCommands []GameCommand
+}
+
+// GameCommand is a slash command of a game and its handler
+type GameCommand struct {
+	Command *discordgo.ApplicationCommand
+	Handler func(s Session, i *discordgo.InteractionCreate)
+}
+
+var gameRegistry = make(map[int]*GameInfo)
+
+// RegisterGame adds a game to the bot, along with its slash commands and component handlers.
+// Games register themselves from an init function.
+func RegisterGame(info *GameInfo) {
+	gameRegistry[info.Type] = info
+	if info.Command != nil {
+		commands = append(commands, info.Command)
+		commandHandlers[info.Command.Name] = info.CommandHandler
+	}
+	for _, extra := range info.ExtraCommands {
+		commands = append(commands, extra.Command)
+		commandHandlers[extra.Command.Name] = extra.Handler
+	}
+	if info.ComponentHandler != nil {
+		componentHandlers[info.ComponentPrefix] = info.ComponentHandler
+	}
+	if info.MessageHandler != nil {
+		messageHandlers[info.MessageCommand] = info.MessageHandler
+	}
+}
+
+// registeredGames returns every registered game, ordered by type
+func registeredGames() []*GameInfo {
+	games := make([]*GameInfo, 0, len(gameRegistry))
+	for _, info := range gameRegistry {
+		games = append(games, info)
+	}
+	sort.Slice(games, func(a, b int) bool {
+		return games[a].Type < games[b].Type
+	})
+	return games
+}
+
+// gameName returns the display name of a game type
+func gameName(gameType int) string {
+	if info, ok := gameRegistry[gameType]; ok {
+		return info.Name
+	}
+	return "cards"
+}
+
+// runningGame returns the game of the given type running in the interaction's channel, or nil if there is none
+func runningGame(i *discordgo.InteractionCreate, gameType int) Game {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != gameType {
+		return nil
+	}
+	return channel.game.current
+}
+
+// endGame resets the channel a game was running in, unless another game has replaced it since.
+// Games call it once they are over. The server state has to be locked before the game,
+// so the reset happens once the caller lets go of the game.
+func endGame(guildID string, channelID string, game Game) {
+	go func() {
+		state := GetServerState(guildID)
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		channel := state.Channel(channelID)
+		if channel.game.current == game {
+			resetState(state, channel)
+		}
+	}()
+}
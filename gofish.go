@@ -0,0 +1,686 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: GoFish,
+		Name: "Go Fish",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "gofish",
+			Description: "Start a game of Go Fish with the channel's deck.",
+		},
+		CommandHandler:   goFishCommand,
+		ComponentPrefix:  "gofish",
+		ComponentHandler: goFishComponent,
+	})
+}
+
+// Limits for games of Go Fish
+const (
+	MaxGoFishPlayers  = 6
+	goFishTurnTimeout = 60 * time.Second
+	goFishLogLength   = 6
+	// goFishBookSize is how many cards of the same rank make a book
+	goFishBookSize = 4
+)
+
+type goFishPlayer struct {
+	userID string
+	name   string
+	hand   playingcards.Hand
+	// books holds the values of the ranks the player collected all four cards of
+	books []int
+}
+
+// GoFishGame holds the state of a game of Go Fish. The pond is the channel's deck.
+type GoFishGame struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+	deck       *playingcards.Deck
+
+	players   []*goFishPlayer
+	started   bool
+	over      bool
+	closed    bool
+	turn      int
+	turnToken int
+	// idleTurns counts the turns in a row that timed out
+	idleTurns int
+	log       []string
+}
+
+// NewGoFishGame creates a game of Go Fish that players can join. The cards are drawn from deck,
+// which the game owns until it is over.
+func NewGoFishGame(guildID string, channelID string, cardsStyle int, deck *playingcards.Deck) *GoFishGame {
+	return &GoFishGame{
+		guildID:    guildID,
+		channelID:  channelID,
+		cardsStyle: cardsStyle,
+		deck:       deck,
+	}
+}
+
+// Lock locks the game's state
+func (g *GoFishGame) Lock() {
+	g.mu.Lock()
+}
+
+// Unlock unlocks the game's state
+func (g *GoFishGame) Unlock() {
+	g.mu.Unlock()
+}
+
+// End stops the game, so pending timers and button presses are ignored
+func (g *GoFishGame) End() {
+	g.closed = true
+}
+
+func (g *GoFishGame) addLog(format string, a ...interface{}) {
+	g.log = append(g.log, fmt.Sprintf(format, a...))
+	if len(g.log) > goFishLogLength {
+		g.log = g.log[len(g.log)-goFishLogLength:]
+	}
+}
+
+func (g *GoFishGame) player(userID string) *goFishPlayer {
+	for _, player := range g.players {
+		if player.userID == userID {
+			return player
+		}
+	}
+	return nil
+}
+
+// setName sets the name shown for a player in the menus, where mentions don't work
+func (g *GoFishGame) setName(userID string, name string) {
+	if player := g.player(userID); player != nil && name != "" {
+		player.name = name
+	}
+}
+
+// opponents returns the other players who have cards to ask for, starting after the given player
+func (g *GoFishGame) opponents(userID string) []*goFishPlayer {
+	start := 0
+	for n, player := range g.players {
+		if player.userID == userID {
+			start = n
+		}
+	}
+	opponents := []*goFishPlayer{}
+	for k := 1; k < len(g.players); k++ {
+		player := g.players[(start+k)%len(g.players)]
+		if player.hand.Size() > 0 {
+			opponents = append(opponents, player)
+		}
+	}
+	return opponents
+}
+
+// Join adds a player before the game starts
+func (g *GoFishGame) Join(userID string) error {
+	if g.started {
+		return errors.New("The game has already started.")
+	}
+	if g.player(userID) != nil {
+		return errors.New("You already joined the game.")
+	}
+	if len(g.players) >= MaxGoFishPlayers {
+		return errors.New("The game is full.")
+	}
+	g.players = append(g.players, &goFishPlayer{userID: userID, name: fmt.Sprintf("Player %d", len(g.players)+1)})
+	return nil
+}
+
+// Start shuffles the deck and deals 7 cards to each player, or 5 cards with four players or more
+func (g *GoFishGame) Start(userID string) (bool, error) {
+	if g.started {
+		return false, errors.New("The game has already started.")
+	}
+	if g.player(userID) == nil {
+		return false, errors.New("Only players in the game can start it.")
+	}
+	if len(g.players) < 2 {
+		return false, errors.New("Go Fish needs at least two players.")
+	}
+	handSize := 7
+	if len(g.players) >= 4 {
+		handSize = 5
+	}
+	g.deck.Shuffle()
+	for round := 0; round < handSize; round++ {
+		for _, player := range g.players {
+			card, err := g.deck.Draw()
+			if err != nil {
+				return false, errors.New("There aren't enough cards in the deck to deal.")
+			}
+			player.hand.Add(card)
+		}
+	}
+	g.started = true
+	g.addLog("Dealt %d cards to each player.", handSize)
+	for _, player := range g.players {
+		g.collectBooks(player)
+	}
+	g.prepareTurn()
+	return g.over, nil
+}
+
+// Action asks another player for a rank. The action is "ask:" followed by the other player's ID,
+// and amount is the value of the rank, 1 for aces up to 13 for kings. It returns true once the game is over.
+func (g *GoFishGame) Action(userID string, action string, amount int) (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	if g.players[g.turn].userID != userID {
+		return false, errors.New("It's not your turn.")
+	}
+	if !strings.HasPrefix(action, "ask:") {
+		return false, errors.New("Unknown action.")
+	}
+	target := g.player(strings.TrimPrefix(action, "ask:"))
+	if target == nil || target.userID == userID {
+		return false, errors.New("You can only ask another player in the game.")
+	}
+	if target.hand.Size() == 0 {
+		return false, fmt.Errorf("%s has no cards left.", target.name)
+	}
+	if countRank(g.players[g.turn].hand, amount) == 0 {
+		return false, errors.New("You can only ask for a rank you have in your hand.")
+	}
+	g.idleTurns = 0
+	g.ask(target, amount)
+	return g.over, nil
+}
+
+// Timeout asks for the player who ran out of time, for a random rank in their hand from a random player.
+// The game ends if every player let their turn time out twice in a row. It returns true once the game is over.
+func (g *GoFishGame) Timeout() (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	g.idleTurns++
+	if g.idleTurns >= 2*len(g.players) {
+		g.addLog("Nobody has played for a while, so the game is over.")
+		g.over = true
+		g.turnToken++
+		return true, nil
+	}
+	asker := g.players[g.turn]
+	opponents := g.opponents(asker.userID)
+	g.addLog("%s took too long, so a card was picked for them.", mention(asker.userID))
+	g.ask(opponents[rand.Intn(len(opponents))], asker.hand.Card(rand.Intn(asker.hand.Size())).Value())
+	return g.over, nil
+}
+
+// ask has the current player ask the target for every card of the given rank. If the target has none,
+// the player goes fishing in the deck. The player goes again if they got what they asked for.
+func (g *GoFishGame) ask(target *goFishPlayer, rank int) {
+	asker := g.players[g.turn]
+	again := false
+	if taken := takeRank(&target.hand, rank); len(taken) > 0 {
+		asker.hand.Add(taken...)
+		g.addLog("%s asked %s for %s and got %d.", mention(asker.userID), mention(target.userID), rankName(rank, true), len(taken))
+		again = true
+	} else {
+		g.addLog("%s asked %s for %s. **Go fish!**", mention(asker.userID), mention(target.userID), rankName(rank, true))
+		if card, err := g.deck.Draw(); err != nil {
+			g.addLog("The pond is empty.")
+		} else {
+			asker.hand.Add(card)
+			if card.Value() == rank {
+				g.addLog("%s fished the %s they asked for and goes again.", mention(asker.userID), card.String())
+				again = true
+			}
+		}
+	}
+	g.collectBooks(asker)
+	g.refill()
+	if !again {
+		g.turn = (g.turn + 1) % len(g.players)
+	}
+	g.prepareTurn()
+	g.turnToken++
+}
+
+// collectBooks lays down every complete book in the player's hand
+func (g *GoFishGame) collectBooks(player *goFishPlayer) {
+	for value := 1; value <= 13; value++ {
+		if countRank(player.hand, value) == goFishBookSize {
+			takeRank(&player.hand, value)
+			player.books = append(player.books, value)
+			g.addLog("%s completed a book of %s!", mention(player.userID), rankName(value, true))
+		}
+	}
+}
+
+// refill has every player who ran out of cards draw one from the pond, while it lasts
+func (g *GoFishGame) refill() {
+	for _, player := range g.players {
+		if player.hand.Size() > 0 {
+			continue
+		}
+		if card, err := g.deck.Draw(); err == nil {
+			player.hand.Add(card)
+			g.addLog("%s ran out of cards and drew one from the pond.", mention(player.userID))
+		}
+	}
+}
+
+// prepareTurn passes the turn on to the next player with cards. The game is over once nobody has any,
+// since then every book has been made.
+func (g *GoFishGame) prepareTurn() {
+	for k := 0; k < len(g.players); k++ {
+		player := g.players[(g.turn+k)%len(g.players)]
+		if player.hand.Size() > 0 && len(g.opponents(player.userID)) > 0 {
+			g.turn = (g.turn + k) % len(g.players)
+			return
+		}
+	}
+	g.over = true
+}
+
+// countRank returns how many cards of the given value are in the hand
+func countRank(hand playingcards.Hand, value int) int {
+	count := 0
+	for _, card := range hand.Cards() {
+		if card.Value() == value {
+			count++
+		}
+	}
+	return count
+}
+
+// takeRank removes every card of the given value from the hand and returns them
+func takeRank(hand *playingcards.Hand, value int) []playingcards.Card {
+	taken := []playingcards.Card{}
+	for n := hand.Size() - 1; n >= 0; n-- {
+		if hand.Card(n).Value() == value {
+			card, _ := hand.RemoveAt(n)
+			taken = append(taken, card)
+		}
+	}
+	return taken
+}
+
+// rankName returns the name of a rank, like "Queen" or "Queens"
+func rankName(value int, plural bool) string {
+	name := playingcards.NewCard(value, playingcards.SPADES).NumberAsString()
+	if plural {
+		return name + "s"
+	}
+	return name
+}
+
+// scoreboard returns the players ordered by the number of books they made, most first
+func (g *GoFishGame) scoreboard() []*goFishPlayer {
+	players := make([]*goFishPlayer, len(g.players))
+	copy(players, g.players)
+	sort.SliceStable(players, func(a, b int) bool {
+		return len(players[a].books) > len(players[b].books)
+	})
+	return players
+}
+
+// Discord side of the game
+
+// goFishCommand handles the /gofish slash command by opening a game for players to join.
+// The game draws from the channel's deck, which is replaced by a fresh 52-card deck for it.
+func goFishCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	game := NewGoFishGame(i.GuildID, i.ChannelID, state.cardsStyle, channel.setStandardDeck())
+	userID := interactionUserID(i)
+	game.Join(userID)
+	game.setName(userID, interactionUserName(i))
+	channel.game = GameState{gameType: GoFish, current: game}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+			Components: goFishLobbyComponents(),
+		},
+	})
+}
+
+func (g *GoFishGame) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	for _, player := range g.players {
+		players.WriteString(fmt.Sprintf("%s\n", mention(player.userID)))
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "Go Fish",
+		Description: "Press **Join** to play, then any player can press **Start** to deal.\nAsk the other players for cards to collect books of all four cards of a rank.",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", len(g.players), MaxGoFishPlayers),
+				Value: players.String(),
+			},
+		},
+	}
+}
+
+func goFishLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "gofish:join"},
+				discordgo.Button{Label: "Start", Style: discordgo.PrimaryButton, CustomID: "gofish:start"},
+			},
+		},
+	}
+}
+
+// goFishComponent handles the buttons and menus of a game of Go Fish
+func goFishComponent(s Session, i *discordgo.InteractionCreate) {
+	game, _ := runningGame(i, GoFish).(*GoFishGame)
+	if game == nil {
+		respondEphemeral(s, i, "This game of Go Fish is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if game.closed {
+		respondEphemeral(s, i, "This game of Go Fish is no longer running.")
+		return
+	}
+
+	data := i.MessageComponentData()
+	switch {
+	case data.CustomID == "gofish:join":
+		if err := game.Join(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		game.setName(userID, interactionUserName(i))
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: goFishLobbyComponents(),
+			},
+		})
+	case data.CustomID == "gofish:start":
+		over, err := game.Start(userID)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		embed, components := game.render()
+		message, err := s.ChannelMessageSendComplex(game.channelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		})
+		if err != nil {
+			s.ChannelMessageSend(game.channelID, "Error found while running the game. Exiting...")
+			game.end()
+			return
+		}
+		game.messageID = message.ID
+		game.afterTurn(s, over)
+	case data.CustomID == "gofish:hand":
+		if game.player(userID) == nil {
+			respondEphemeral(s, i, "You are not playing in this game.")
+			return
+		}
+		if !game.started {
+			respondEphemeral(s, i, "The game hasn't started yet.")
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: game.handPanel(userID, ""),
+		})
+	case data.CustomID == "gofish:target":
+		if game.player(userID) == nil || len(data.Values) == 0 {
+			respondEphemeral(s, i, "You are not playing in this game.")
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: game.handPanel(userID, data.Values[0]),
+		})
+	case strings.HasPrefix(data.CustomID, "gofish:ask:"):
+		if len(data.Values) == 0 {
+			respondEphemeral(s, i, "Pick a rank to ask for.")
+			return
+		}
+		rank, err := strconv.Atoi(data.Values[0])
+		if err != nil {
+			respondEphemeral(s, i, "Pick a rank to ask for.")
+			return
+		}
+		over, err := game.Action(userID, strings.TrimPrefix(data.CustomID, "gofish:"), rank)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: game.handPanel(userID, ""),
+		})
+		game.afterTurn(s, over)
+	}
+}
+
+// afterTurn refreshes the game message, and either waits for the next player or ends the game. The game must be locked.
+func (g *GoFishGame) afterTurn(s Session, over bool) {
+	g.updateMessage(s)
+	if over {
+		g.end()
+		return
+	}
+	g.scheduleTimeout(s)
+}
+
+// end stops the game and resets its channel. The game must be locked.
+func (g *GoFishGame) end() {
+	g.End()
+	endGame(g.guildID, g.channelID, g)
+}
+
+// scheduleTimeout asks for the current player if they take too long. The game must be locked.
+func (g *GoFishGame) scheduleTimeout(s Session) {
+	token := g.turnToken
+	time.AfterFunc(goFishTurnTimeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed || g.turnToken != token {
+			return
+		}
+		over, err := g.Timeout()
+		if err != nil {
+			return
+		}
+		g.afterTurn(s, over)
+	})
+}
+
+// updateMessage edits the game message to show the current state of the game. The game must be locked.
+func (g *GoFishGame) updateMessage(s Session) {
+	embed, components := g.render()
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         g.messageID,
+		Channel:    g.channelID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
+
+// bookNames lists the ranks of the books a player made, like "A, 7, K"
+func bookNames(books []int) string {
+	if len(books) == 0 {
+		return "none"
+	}
+	names := make([]string, len(books))
+	for n, value := range books {
+		names[n] = strings.TrimSuffix(playingcards.NewCard(value, playingcards.SPADES).Short(), "S")
+	}
+	return strings.Join(names, ", ")
+}
+
+// render builds the public game message: the last moves, each player's cards and books, and the buttons
+func (g *GoFishGame) render() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var description strings.Builder
+	description.WriteString(strings.Join(g.log, "\n"))
+	description.WriteString("\n\n")
+	if g.over {
+		description.WriteString("**Final scores**\n")
+		scoreboard := g.scoreboard()
+		for n, player := range scoreboard {
+			description.WriteString(fmt.Sprintf("%d. %s: %d books (%s)\n", n+1, mention(player.userID), len(player.books), bookNames(player.books)))
+		}
+		winners := []string{}
+		for _, player := range scoreboard {
+			if len(player.books) == len(scoreboard[0].books) {
+				winners = append(winners, mention(player.userID))
+			}
+		}
+		if len(winners) == 1 {
+			description.WriteString(fmt.Sprintf("\n**%s wins the game!**", winners[0]))
+		} else {
+			description.WriteString(fmt.Sprintf("\n**It's a tie between %s!**", strings.Join(winners, " and ")))
+		}
+	} else {
+		for n, player := range g.players {
+			marker := "▫️"
+			if n == g.turn {
+				marker = "▶️"
+			}
+			description.WriteString(fmt.Sprintf("%s %s: %d cards, books: %s\n", marker, mention(player.userID), player.hand.Size(), bookNames(player.books)))
+		}
+		description.WriteString(fmt.Sprintf("\n%s to ask. Press **My hand** to see your cards and ask for a rank.", mention(g.players[g.turn].userID)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "Go Fish",
+		Description: description.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d cards left in the pond.", g.deck.Size()),
+		},
+	}
+	if g.over {
+		return embed, []discordgo.MessageComponent{}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "My hand", Style: discordgo.SecondaryButton, CustomID: "gofish:hand", Emoji: discordgo.ComponentEmoji{Name: "🐟"}},
+			},
+		},
+	}
+}
+
+// handPanel returns the message showing a player their hand. On their turn, it also has the menus to pick
+// who to ask, targetID or else the next player, and the rank to ask for.
+func (g *GoFishGame) handPanel(userID string, targetID string) *discordgo.InteractionResponseData {
+	player := g.player(userID)
+	player.hand.SortByRank(playingcards.AceLow)
+	cards := player.hand.Cards()
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Your hand (%d cards)", len(cards)),
+		Description: cardNames(cards),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Your books: %s", bookNames(player.books)),
+		},
+	}
+	if len(cards) == 0 {
+		embed.Description = "You have no cards."
+	} else {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(cards, g.cardsStyle, LayoutFan)}
+	}
+	data := &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{},
+		Flags:      discordgo.MessageFlagsEphemeral,
+	}
+	if g.over || g.players[g.turn].userID != userID {
+		if !g.over {
+			data.Content = fmt.Sprintf("It's %s's turn. Press **My hand** again to refresh your cards.", g.players[g.turn].name)
+		}
+		return data
+	}
+
+	opponents := g.opponents(userID)
+	target := opponents[0]
+	for _, opponent := range opponents {
+		if opponent.userID == targetID {
+			target = opponent
+		}
+	}
+	targetOptions := make([]discordgo.SelectMenuOption, len(opponents))
+	for n, opponent := range opponents {
+		targetOptions[n] = discordgo.SelectMenuOption{
+			Label:       opponent.name,
+			Value:       opponent.userID,
+			Description: fmt.Sprintf("%d cards", opponent.hand.Size()),
+			Default:     opponent == target,
+		}
+	}
+	rankOptions := []discordgo.SelectMenuOption{}
+	for value := 1; value <= 13; value++ {
+		if count := countRank(player.hand, value); count > 0 {
+			rankOptions = append(rankOptions, discordgo.SelectMenuOption{
+				Label:       rankName(value, true),
+				Value:       strconv.Itoa(value),
+				Description: fmt.Sprintf("You have %d", count),
+			})
+		}
+	}
+	data.Content = "It's your turn! Pick who to ask, then the rank to ask them for."
+	data.Components = []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "gofish:target", Placeholder: "Player to ask", Options: targetOptions},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "gofish:ask:" + target.userID, Placeholder: fmt.Sprintf("Rank to ask %s for", target.name), Options: rankOptions},
+			},
+		},
+	}
+	return data
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGoFishMenus asks for cards in Go Fish through the select menus of the hand panel
+func TestGoFishMenus(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("menus-gofish"), "table"
+	lobby, _ := f.InteractionResponse(f.Command(guildID, channelID, "101", "gofish").Interaction)
+	f.Press(guildID, "102", lobby, "gofish:join")
+	f.Press(guildID, "101", lobby, "gofish:start")
+	table := lastMessage(t, f, channelID)
+
+	// Only the player whose turn it is gets the menus
+	for _, userID := range []string{"101", "102"} {
+		panel := ephemeralMessage(t, f, f.Press(guildID, userID, table, "gofish:hand"))
+		if selectMenu(panel, "gofish:target") == nil {
+			continue
+		}
+		panel = ephemeralMessage(t, f, f.Choose(guildID, userID, panel, "gofish:target", selectMenu(panel, "gofish:target").Options[0].Value))
+		ask := selectMenu(panel, "gofish:ask:")
+		if ask == nil {
+			t.Fatal("picking a player shows no menu of ranks")
+		}
+		f.Choose(guildID, userID, panel, ask.CustomID, ask.Options[0].Value)
+		if text := messageText(lastMessage(t, f, channelID).Content, lastMessage(t, f, channelID).Embeds); !strings.Contains(text, "asked") {
+			t.Errorf("the table doesn't show the question: %q", text)
+		}
+		f.Command(guildID, channelID, "101", "quit-game")
+		return
+	}
+	t.Error("neither player got the menus to ask for cards")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// mentionPattern matches user mentions like <@123> and <@!123>
+var mentionPattern = regexp.MustCompile(`<@!?(\d+)>`)
+
+// parseMentions returns the IDs of the users mentioned in the text, in order and without duplicates
+func parseMentions(text string) []string {
+	userIDs := []string{}
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			userIDs = append(userIDs, match[1])
+		}
+	}
+	return userIDs
+}
+
+// dealCards deals count cards from the channel's deck to each player, one card at a time around the players.
+// A player's hand is their zone of the channel, which also holds the cards they drew.
+// It returns a status message in response. The state must be locked.
+func dealCards(state *ServerState, channel *ChannelState, players []string, count int) string {
+	if channel.GameType() != NoGame {
+		return gameInProgressWarning()
+	}
+	if len(players) == 0 {
+		return "Mention the players to deal to, like `@alice @bob`."
+	}
+	if count < 1 {
+		return "The number of cards to deal must be at least 1."
+	}
+	if needed := count * len(players); needed > channel.deck.Size() {
+		return fmt.Sprintf("Can't deal %d cards, only %d cards are left in the deck.", needed, channel.deck.Size())
+	}
+	for round := 0; round < count; round++ {
+		for _, player := range players {
+			channel.zones.Deal(&channel.deck, playingcards.PlayerZone(player), 1)
+		}
+	}
+	state.save()
+
+	mentions := make([]string, len(players))
+	for n, player := range players {
+		mentions[n] = mention(player)
+	}
+	cardString := "cards"
+	if count == 1 {
+		cardString = "card"
+	}
+	return fmt.Sprintf("Dealt %d %s to %s. Use /hand to see your cards. %d cards remaining.",
+		count, cardString, strings.Join(mentions, ", "), channel.deck.Size())
+}
+
+// handResponse returns the message showing a player their own cards. The state must be locked.
+func handResponse(state *ServerState, channel *ChannelState, userID string) *discordgo.InteractionResponseData {
+	cards := channel.zones.Zone(playingcards.PlayerZone(userID)).Cards()
+	if len(cards) == 0 {
+		return &discordgo.InteractionResponseData{
+			Content: "You have no cards. Cards you draw or are dealt show up here.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	message := &discordgo.MessageEmbed{
+		Color:       0x7fb2f0,
+		Title:       fmt.Sprintf("Your hand (%d cards)", len(cards)),
+		Description: cardNames(cards),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Only you can see this. Use /play to show a card to the channel.",
+		},
+	}
+	// Hands too big for one image are only listed by name
+	if len(cards) <= MaxRenderCount {
+		message.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(cards, state.cardsStyle, LayoutFan)}
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	}
+}
+
+// playCardResponse moves a card from the player's hand onto the table, and returns the message revealing it.
+// The state must be locked.
+func playCardResponse(state *ServerState, channel *ChannelState, userID string, cardText string) *discordgo.InteractionResponseData {
+	if channel.GameType() != NoGame {
+		return &discordgo.InteractionResponseData{
+			Content: gameInProgressWarning(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	card, err := playingcards.ParseCard(cardText)
+	if err != nil {
+		return &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Couldn't read the card %q. Write cards like `AS`, `10H` or `Queen of Hearts`.", cardText),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	played, err := channel.zones.MoveCard(playingcards.PlayerZone(userID), playingcards.ZoneTable, card)
+	if err != nil {
+		return &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("You don't have the %s.", card.String()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	state.save()
+	message := &discordgo.MessageEmbed{
+		Color:       0x7fb2f0,
+		Title:       played.String(),
+		Description: fmt.Sprintf("%s played a card.", mention(userID)),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d cards left in their hand.", channel.zones.Zone(playingcards.PlayerZone(userID)).Size()),
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: GetCardURL(played, state.cardsStyle),
+		},
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+	}
+}
@@ -0,0 +1,1001 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: Hearts,
+		Name: "Hearts",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "hearts",
+			Description: "Start a game of Hearts for four players. Empty seats are taken by bots.",
+		},
+		CommandHandler:   heartsCommand,
+		ComponentPrefix:  "hearts",
+		ComponentHandler: heartsComponent,
+	})
+}
+
+// Rules and limits for games of Hearts
+const (
+	HeartsSeats = 4
+	// HeartsEndScore ends the game once a player reaches it, and the lowest score wins
+	HeartsEndScore    = 100
+	heartsPassCount   = 3
+	heartsTricks      = 13
+	heartsMoonPoints  = 26
+	heartsTurnTimeout = 60 * time.Second
+	heartsHandDelay   = 8 * time.Second
+	heartsLogLength   = 5
+)
+
+// Directions cards are passed in before each hand, in the order they rotate through
+const (
+	heartsPassLeft = iota
+	heartsPassRight
+	heartsPassAcross
+	heartsPassHold
+)
+
+var heartsPassNames = map[int]string{
+	heartsPassLeft:   "to the left",
+	heartsPassRight:  "to the right",
+	heartsPassAcross: "across",
+	heartsPassHold:   "hold",
+}
+
+var (
+	queenOfSpades = playingcards.NewCard(12, playingcards.SPADES)
+	twoOfClubs    = playingcards.NewCard(2, playingcards.CLUBS)
+)
+
+type heartsSeat struct {
+	// userID is empty for a bot
+	userID string
+	hand   playingcards.Hand
+	// passing holds the cards picked to pass, nil until they are picked
+	passing []playingcards.Card
+	// taken is the points won in tricks this hand, and score the total of the previous hands
+	taken int
+	score int
+}
+
+func (p *heartsSeat) isBot() bool {
+	return p.userID == ""
+}
+
+// HeartsGame holds the state of a game of Hearts. The seats go clockwise, so passing left gives the cards to the next seat.
+type HeartsGame struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+	deck       *playingcards.Deck
+
+	seats   [HeartsSeats]*heartsSeat
+	started bool
+	over    bool
+	// abandoned is whether the game was stopped because nobody played, in the middle of a hand
+	abandoned  bool
+	closed     bool
+	handNumber int
+	passing    bool
+	// trick holds the cards played to the current trick, starting with the card led by the leader
+	trick        []playingcards.Card
+	leader       int
+	turn         int
+	tricksPlayed int
+	heartsBroken bool
+	// lastTrick is the last trick that was taken, which is shown until the next card is played
+	lastTrick  []playingcards.Card
+	lastPoints [HeartsSeats]int
+	turnToken  int
+	// idleTurns counts the timeouts in a row
+	idleTurns int
+	log       []string
+}
+
+// NewHeartsGame creates a game of Hearts that players can join. Each hand is dealt from a new standard deck put in deck,
+// which the game owns until it is over.
+func NewHeartsGame(guildID string, channelID string, cardsStyle int, deck *playingcards.Deck) *HeartsGame {
+	return &HeartsGame{
+		guildID:    guildID,
+		channelID:  channelID,
+		cardsStyle: cardsStyle,
+		deck:       deck,
+	}
+}
+
+// Lock locks the game's state
+func (g *HeartsGame) Lock() {
+	g.mu.Lock()
+}
+
+// Unlock unlocks the game's state
+func (g *HeartsGame) Unlock() {
+	g.mu.Unlock()
+}
+
+// End stops the game, so pending timers and button presses are ignored
+func (g *HeartsGame) End() {
+	g.closed = true
+}
+
+func (g *HeartsGame) addLog(format string, a ...interface{}) {
+	g.log = append(g.log, fmt.Sprintf(format, a...))
+	if len(g.log) > heartsLogLength {
+		g.log = g.log[len(g.log)-heartsLogLength:]
+	}
+}
+
+func (g *HeartsGame) seatIndex(userID string) int {
+	for i, seat := range g.seats {
+		if seat != nil && !seat.isBot() && seat.userID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// seatName returns how a seat is shown in messages: a mention, or the bot's name
+func (g *HeartsGame) seatName(i int) string {
+	if g.seats[i].isBot() {
+		return fmt.Sprintf("🤖 Bot %d", i+1)
+	}
+	return mention(g.seats[i].userID)
+}
+
+// passDirection returns which way cards are passed this hand
+func (g *HeartsGame) passDirection() int {
+	return (g.handNumber - 1) % 4
+}
+
+// passTarget returns the seat the given seat passes its cards to
+func (g *HeartsGame) passTarget(i int) int {
+	switch g.passDirection() {
+	case heartsPassLeft:
+		return (i + 1) % HeartsSeats
+	case heartsPassAcross:
+		return (i + 2) % HeartsSeats
+	case heartsPassRight:
+		return (i + 3) % HeartsSeats
+	}
+	return i
+}
+
+// Join takes an empty seat before the game starts
+func (g *HeartsGame) Join(userID string) error {
+	if g.started {
+		return errors.New("The game has already started.")
+	}
+	if g.seatIndex(userID) >= 0 {
+		return errors.New("You already joined the game.")
+	}
+	for i, seat := range g.seats {
+		if seat == nil {
+			g.seats[i] = &heartsSeat{userID: userID}
+			return nil
+		}
+	}
+	return errors.New("The game is full.")
+}
+
+// Start seats bots in the empty seats and deals the first hand
+func (g *HeartsGame) Start(userID string) (bool, error) {
+	if g.started {
+		return false, errors.New("The game has already started.")
+	}
+	if g.seatIndex(userID) < 0 {
+		return false, errors.New("Only players in the game can start it.")
+	}
+	for i, seat := range g.seats {
+		if seat == nil {
+			g.seats[i] = &heartsSeat{}
+		}
+	}
+	g.started = true
+	return g.startHand(), nil
+}
+
+// startHand shuffles a new deck and deals 13 cards to each seat. It returns true if the hand is already over.
+func (g *HeartsGame) startHand() bool {
+	g.handNumber++
+	*g.deck = newStandardDeck()
+	g.deck.Shuffle()
+	for _, seat := range g.seats {
+		seat.hand.Clear()
+		seat.passing = nil
+		seat.taken = 0
+	}
+	for n := 0; n < heartsTricks; n++ {
+		for _, seat := range g.seats {
+			seat.hand.Add(g.deck.DrawCard())
+		}
+	}
+	g.trick, g.lastTrick = nil, nil
+	g.tricksPlayed = 0
+	g.heartsBroken = false
+	g.turnToken++
+
+	if g.passDirection() == heartsPassHold {
+		g.addLog("Hand #%d: no passing this hand.", g.handNumber)
+		return g.startPlay()
+	}
+	g.passing = true
+	g.addLog("Hand #%d: pass three cards %s.", g.handNumber, heartsPassNames[g.passDirection()])
+	for _, seat := range g.seats {
+		if seat.isBot() {
+			seat.passing = botPass(seat.hand)
+		}
+	}
+	return false
+}
+
+// startPlay passes the cards picked, if it is a passing hand, and has the holder of the 2 of Clubs lead.
+// It returns true if the hand is already over.
+func (g *HeartsGame) startPlay() bool {
+	if g.passing {
+		for i, seat := range g.seats {
+			for _, card := range seat.passing {
+				seat.hand.Remove(card)
+			}
+			g.seats[g.passTarget(i)].hand.Add(seat.passing...)
+		}
+		g.passing = false
+		g.addLog("Everyone passed their cards.")
+	}
+	for i, seat := range g.seats {
+		if seat.hand.Contains(twoOfClubs) {
+			g.leader, g.turn = i, i
+		}
+	}
+	g.turnToken++
+	return g.runBots()
+}
+
+// Action picks the cards to pass with "pass:" and their codes joined with "-", like "pass:QS-AH-KH",
+// or plays a card with "play:" and its code. It returns true once the hand is over.
+func (g *HeartsGame) Action(userID string, action string, amount int) (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	i := g.seatIndex(userID)
+	if i < 0 {
+		return false, errors.New("You are not playing in this game.")
+	}
+	seat := g.seats[i]
+
+	if g.passing {
+		if !strings.HasPrefix(action, "pass:") {
+			return false, errors.New("Pick the cards to pass first.")
+		}
+		if seat.passing != nil {
+			return false, errors.New("You already picked the cards to pass.")
+		}
+		cards := []playingcards.Card{}
+		for _, code := range strings.Split(strings.TrimPrefix(action, "pass:"), "-") {
+			card, err := playingcards.ParseCard(code)
+			if err != nil || !seat.hand.Contains(card) {
+				return false, fmt.Errorf("You don't have the card %q.", code)
+			}
+			for _, picked := range cards {
+				if picked.SameFace(card) {
+					return false, errors.New("Pick three different cards.")
+				}
+			}
+			cards = append(cards, card)
+		}
+		if len(cards) != heartsPassCount {
+			return false, errors.New("Pick exactly three cards to pass.")
+		}
+		seat.passing = cards
+		g.idleTurns = 0
+		for _, other := range g.seats {
+			if other.passing == nil {
+				return false, nil
+			}
+		}
+		return g.startPlay(), nil
+	}
+
+	if !strings.HasPrefix(action, "play:") {
+		return false, errors.New("Unknown action.")
+	}
+	if g.turn != i {
+		return false, errors.New("It's not your turn.")
+	}
+	card, err := playingcards.ParseCard(strings.TrimPrefix(action, "play:"))
+	if err != nil || !seat.hand.Contains(card) {
+		return false, errors.New("You don't have that card.")
+	}
+	legal := false
+	for _, play := range g.legalPlays(i) {
+		legal = legal || play.SameFace(card)
+	}
+	if !legal {
+		return false, g.illegalPlayReason(card)
+	}
+	g.idleTurns = 0
+	if g.play(i, card) {
+		return true, nil
+	}
+	return g.runBots(), nil
+}
+
+// Timeout picks the cards to pass, or plays a card, for the players who ran out of time. The game is abandoned if players
+// let their turn time out eight times in a row. It returns true once the hand is over or the game was abandoned.
+func (g *HeartsGame) Timeout() (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	g.idleTurns++
+	if g.idleTurns >= 2*HeartsSeats {
+		g.addLog("Nobody has played for a while, so the game is over.")
+		g.over = true
+		g.abandoned = true
+		g.turnToken++
+		return true, nil
+	}
+	if g.passing {
+		for i, seat := range g.seats {
+			if seat.passing == nil {
+				seat.passing = botPass(seat.hand)
+				g.addLog("%s took too long, so cards were picked for them.", g.seatName(i))
+			}
+		}
+		return g.startPlay(), nil
+	}
+	g.addLog("%s took too long, so a card was played for them.", g.seatName(g.turn))
+	if g.play(g.turn, g.botPlay(g.turn)) {
+		return true, nil
+	}
+	return g.runBots(), nil
+}
+
+// runBots plays for the bots until it is a player's turn. It returns true if the hand is over.
+func (g *HeartsGame) runBots() bool {
+	for !g.passing && g.seats[g.turn].isBot() {
+		if g.play(g.turn, g.botPlay(g.turn)) {
+			return true
+		}
+	}
+	return false
+}
+
+// legalPlays returns the cards the seat can play. Players must follow the suit led if they can. The 2 of Clubs leads
+// the first trick, where no points can be played unless there is no other choice. Hearts can't be led until they are broken.
+func (g *HeartsGame) legalPlays(i int) []playingcards.Card {
+	hand := g.seats[i].hand.Cards()
+	filter := func(cards []playingcards.Card, keep func(playingcards.Card) bool) []playingcards.Card {
+		kept := []playingcards.Card{}
+		for _, card := range cards {
+			if keep(card) {
+				kept = append(kept, card)
+			}
+		}
+		if len(kept) == 0 {
+			return cards
+		}
+		return kept
+	}
+	if len(g.trick) == 0 {
+		if g.tricksPlayed == 0 {
+			return filter(hand, func(c playingcards.Card) bool { return c.SameFace(twoOfClubs) })
+		}
+		if !g.heartsBroken {
+			return filter(hand, func(c playingcards.Card) bool { return c.Suit() != playingcards.HEARTS })
+		}
+		return hand
+	}
+	led := g.trick[0].Suit()
+	following := []playingcards.Card{}
+	for _, card := range hand {
+		if card.Suit() == led {
+			following = append(following, card)
+		}
+	}
+	if len(following) > 0 {
+		return following
+	}
+	if g.tricksPlayed == 0 {
+		return filter(hand, func(c playingcards.Card) bool { return heartsPoints(c) == 0 })
+	}
+	return hand
+}
+
+// illegalPlayReason explains why a card can't be played
+func (g *HeartsGame) illegalPlayReason(card playingcards.Card) error {
+	switch {
+	case len(g.trick) == 0 && g.tricksPlayed == 0:
+		return errors.New("The 2 of Clubs leads the first trick.")
+	case len(g.trick) == 0:
+		return errors.New("Hearts can't be led until they are broken.")
+	case card.Suit() != g.trick[0].Suit() && g.canFollow(g.turn):
+		return fmt.Errorf("You have to follow suit and play %s.", g.trick[0].Suit().String())
+	}
+	return errors.New("Points can't be played on the first trick.")
+}
+
+func (g *HeartsGame) canFollow(i int) bool {
+	for _, card := range g.seats[i].hand.Cards() {
+		if card.Suit() == g.trick[0].Suit() {
+			return true
+		}
+	}
+	return false
+}
+
+// heartsPoints returns the points a card is worth to whoever takes it
+func heartsPoints(card playingcards.Card) int {
+	if card.Suit() == playingcards.HEARTS {
+		return 1
+	}
+	if card.SameFace(queenOfSpades) {
+		return 13
+	}
+	return 0
+}
+
+func pointsText(points int) string {
+	if points == 1 {
+		return "1 point"
+	}
+	return fmt.Sprintf("%d points", points)
+}
+
+// play plays a card for the seat whose turn it is, and takes the trick once everyone played.
+// It returns true if that was the last trick of the hand.
+func (g *HeartsGame) play(i int, card playingcards.Card) bool {
+	g.seats[i].hand.Remove(card)
+	g.trick = append(g.trick, card)
+	g.lastTrick = nil
+	if card.Suit() == playingcards.HEARTS && !g.heartsBroken {
+		g.heartsBroken = true
+		g.addLog("Hearts have been broken!")
+	}
+	g.turnToken++
+	if len(g.trick) < HeartsSeats {
+		g.turn = (g.turn + 1) % HeartsSeats
+		return false
+	}
+
+	winner, best, points := 0, -1, 0
+	for n, played := range g.trick {
+		points += heartsPoints(played)
+		if played.Suit() == g.trick[0].Suit() && playingcards.AceHigh.Rank(played) > best {
+			best = playingcards.AceHigh.Rank(played)
+			winner = (g.leader + n) % HeartsSeats
+		}
+	}
+	g.seats[winner].taken += points
+	g.addLog("%s takes the trick with the %s (%s).", g.seatName(winner), g.trick[(winner-g.leader+HeartsSeats)%HeartsSeats].String(), pointsText(points))
+	g.lastTrick, g.trick = g.trick, nil
+	g.tricksPlayed++
+	g.leader, g.turn = winner, winner
+	if g.tricksPlayed == heartsTricks {
+		g.finishHand()
+		return true
+	}
+	return false
+}
+
+// finishHand adds the points taken this hand to the scores. A player who took every point shot the moon,
+// and everyone else gets the points instead. The game is over once someone reaches HeartsEndScore.
+func (g *HeartsGame) finishHand() {
+	shooter := -1
+	for i, seat := range g.seats {
+		if seat.taken == heartsMoonPoints {
+			shooter = i
+		}
+	}
+	for i, seat := range g.seats {
+		g.lastPoints[i] = seat.taken
+		if shooter >= 0 {
+			g.lastPoints[i] = heartsMoonPoints
+			if i == shooter {
+				g.lastPoints[i] = 0
+			}
+		}
+		seat.score += g.lastPoints[i]
+		if seat.score >= HeartsEndScore {
+			g.over = true
+		}
+	}
+	if shooter >= 0 {
+		g.addLog("%s shot the moon! 🌙", g.seatName(shooter))
+	}
+	g.turnToken++
+}
+
+// NextHand deals the next hand once the results of the last one were shown. It returns true if the hand is already over.
+func (g *HeartsGame) NextHand() bool {
+	return g.startHand()
+}
+
+// winners returns the seats with the lowest score
+func (g *HeartsGame) winners() []int {
+	winners := []int{}
+	for i, seat := range g.seats {
+		if len(winners) == 0 || seat.score < g.seats[winners[0]].score {
+			winners = []int{i}
+		} else if seat.score == g.seats[winners[0]].score {
+			winners = append(winners, i)
+		}
+	}
+	return winners
+}
+
+// Bot players
+
+// botDanger rates how much a bot wants to get rid of a card: the queen of spades and the spades that could catch her first,
+// then high hearts, then other high cards
+func botDanger(card playingcards.Card) int {
+	rank := playingcards.AceHigh.Rank(card)
+	switch {
+	case card.SameFace(queenOfSpades):
+		return 100
+	case card.Suit() == playingcards.SPADES && rank > 12:
+		return 80 + rank
+	case card.Suit() == playingcards.HEARTS:
+		return 20 + rank
+	}
+	return rank
+}
+
+// botPass picks the three most dangerous cards of the hand to pass
+func botPass(hand playingcards.Hand) []playingcards.Card {
+	cards := hand.Cards()
+	picked := []playingcards.Card{}
+	for len(picked) < heartsPassCount {
+		best := 0
+		for n, card := range cards {
+			if botDanger(card) > botDanger(cards[best]) {
+				best = n
+			}
+		}
+		picked = append(picked, cards[best])
+		cards = append(cards[:best], cards[best+1:]...)
+	}
+	return picked
+}
+
+// botPlay picks a card for the seat. Bots lead their lowest card and duck under the winning card when they can.
+// When they would take the trick anyway they play their highest card, and when they can't follow suit
+// they throw away the queen of spades, then their highest heart, then their most dangerous card.
+func (g *HeartsGame) botPlay(i int) playingcards.Card {
+	plays := g.legalPlays(i)
+	pick := func(better func(a, b playingcards.Card) bool) playingcards.Card {
+		best := plays[0]
+		for _, card := range plays[1:] {
+			if better(card, best) {
+				best = card
+			}
+		}
+		return best
+	}
+	rank := playingcards.AceHigh.Rank
+	lowest := func(a, b playingcards.Card) bool { return rank(a) < rank(b) }
+	if len(g.trick) == 0 {
+		return pick(lowest)
+	}
+
+	led := g.trick[0].Suit()
+	if plays[0].Suit() != led {
+		return pick(func(a, b playingcards.Card) bool { return botDanger(a) > botDanger(b) })
+	}
+	winning := 0
+	for _, played := range g.trick {
+		if played.Suit() == led && rank(played) > winning {
+			winning = rank(played)
+		}
+	}
+	ducking := []playingcards.Card{}
+	for _, card := range plays {
+		if rank(card) < winning {
+			ducking = append(ducking, card)
+		}
+	}
+	if len(ducking) > 0 {
+		plays = ducking
+		return pick(func(a, b playingcards.Card) bool { return rank(a) > rank(b) })
+	}
+	// Taking the trick anyway, so get rid of a high card, but not the queen of spades
+	return pick(func(a, b playingcards.Card) bool {
+		if a.SameFace(queenOfSpades) != b.SameFace(queenOfSpades) {
+			return b.SameFace(queenOfSpades)
+		}
+		return rank(a) > rank(b)
+	})
+}
+
+// Discord side of the game
+
+// heartsCommand handles the /hearts slash command by opening a game for players to join
+func heartsCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	game := NewHeartsGame(i.GuildID, i.ChannelID, state.cardsStyle, channel.setStandardDeck())
+	game.Join(interactionUserID(i))
+	channel.game = GameState{gameType: Hearts, current: game}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+			Components: heartsLobbyComponents(),
+		},
+	})
+}
+
+func (g *HeartsGame) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	joined := 0
+	for i, seat := range g.seats {
+		if seat == nil {
+			players.WriteString(fmt.Sprintf("Seat %d: empty\n", i+1))
+			continue
+		}
+		joined++
+		players.WriteString(fmt.Sprintf("Seat %d: %s\n", i+1, g.seatName(i)))
+	}
+	return &discordgo.MessageEmbed{
+		Color: 0x3dbb6b,
+		Title: "Hearts",
+		Description: fmt.Sprintf("Press **Join** to take a seat, then any player can press **Start** to deal. Empty seats are taken by bots.\n"+
+			"Avoid taking hearts and the Queen of Spades. The game ends when someone reaches %d points, and the lowest score wins.", HeartsEndScore),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", joined, HeartsSeats),
+				Value: players.String(),
+			},
+		},
+	}
+}
+
+func heartsLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "hearts:join"},
+				discordgo.Button{Label: "Start", Style: discordgo.PrimaryButton, CustomID: "hearts:start"},
+			},
+		},
+	}
+}
+
+// heartsComponent handles the buttons and menus of a game of Hearts
+func heartsComponent(s Session, i *discordgo.InteractionCreate) {
+	game, _ := runningGame(i, Hearts).(*HeartsGame)
+	if game == nil {
+		respondEphemeral(s, i, "This game of Hearts is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if game.closed {
+		respondEphemeral(s, i, "This game of Hearts is no longer running.")
+		return
+	}
+
+	data := i.MessageComponentData()
+	switch data.CustomID {
+	case "hearts:join":
+		if err := game.Join(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: heartsLobbyComponents(),
+			},
+		})
+	case "hearts:start":
+		handOver, err := game.Start(userID)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		game.postHand(s, handOver)
+	case "hearts:hand":
+		if game.seatIndex(userID) < 0 {
+			respondEphemeral(s, i, "You are not playing in this game.")
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: game.handPanel(userID),
+		})
+	case "hearts:pass":
+		game.handleAction(s, i, userID, "pass:"+strings.Join(data.Values, "-"))
+	case "hearts:play":
+		if len(data.Values) == 0 {
+			respondEphemeral(s, i, "Pick a card to play.")
+			return
+		}
+		game.handleAction(s, i, userID, "play:"+data.Values[0])
+	}
+}
+
+// handleAction applies a move from a player's hand panel, then refreshes the panel and the table message.
+// The game must be locked.
+func (g *HeartsGame) handleAction(s Session, i *discordgo.InteractionCreate, userID string, action string) {
+	handOver, err := g.Action(userID, action, 0)
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: g.handPanel(userID),
+	})
+	g.afterAction(s, handOver)
+}
+
+// afterAction refreshes the table message and either waits for the next move or wraps up the hand.
+// The game must be locked.
+func (g *HeartsGame) afterAction(s Session, handOver bool) {
+	if !handOver {
+		g.updateMessage(s, true)
+		g.scheduleTimeout(s)
+		return
+	}
+	g.updateMessage(s, false)
+	if g.abandoned {
+		// The hand wasn't finished, so there are no results and nobody won
+		s.ChannelMessageSend(g.channelID, "Game end! Nobody has played for a while, so the game was stopped.")
+		g.end()
+		return
+	}
+	s.ChannelMessageSendEmbed(g.channelID, g.resultsEmbed())
+	if g.over {
+		winners := g.winners()
+		names := make([]string, len(winners))
+		for n, winner := range winners {
+			names[n] = g.seatName(winner)
+		}
+		verb := "wins"
+		if len(winners) > 1 {
+			verb = "win"
+		}
+		s.ChannelMessageSend(g.channelID, fmt.Sprintf("Game end! %s %s the game with %s!",
+			strings.Join(names, " and "), verb, pointsText(g.seats[winners[0]].score)))
+		g.end()
+		return
+	}
+
+	go func() {
+		time.Sleep(heartsHandDelay)
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if !g.closed {
+			g.postHand(s, g.NextHand())
+		}
+	}()
+}
+
+// end stops the game and resets its channel. The game must be locked.
+func (g *HeartsGame) end() {
+	g.End()
+	endGame(g.guildID, g.channelID, g)
+}
+
+// postHand posts a new table message for the hand that was just dealt. The game must be locked.
+func (g *HeartsGame) postHand(s Session, handOver bool) {
+	embed, components := g.render(!handOver)
+	message, err := s.ChannelMessageSendComplex(g.channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		s.ChannelMessageSend(g.channelID, "Error found while running the game. Exiting...")
+		g.end()
+		return
+	}
+	g.messageID = message.ID
+	g.afterAction(s, handOver)
+}
+
+// scheduleTimeout moves for the players who take too long. The game must be locked.
+func (g *HeartsGame) scheduleTimeout(s Session) {
+	token := g.turnToken
+	time.AfterFunc(heartsTurnTimeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed || g.turnToken != token {
+			return
+		}
+		handOver, err := g.Timeout()
+		if err != nil {
+			return
+		}
+		g.afterAction(s, handOver)
+	})
+}
+
+// updateMessage edits the table message to show the current state of the hand. The game must be locked.
+func (g *HeartsGame) updateMessage(s Session, withButtons bool) {
+	embed, components := g.render(withButtons)
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         g.messageID,
+		Channel:    g.channelID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
+
+// render builds the table message: the scores, the last moves, and the cards of the current trick
+func (g *HeartsGame) render(withButtons bool) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var description strings.Builder
+	description.WriteString(strings.Join(g.log, "\n"))
+	description.WriteString("\n\n")
+	for i, seat := range g.seats {
+		marker := "▫️"
+		if withButtons && !g.passing && i == g.turn {
+			marker = "▶️"
+		}
+		description.WriteString(fmt.Sprintf("%s %s: %s, %d this hand\n", marker, g.seatName(i), pointsText(seat.score), seat.taken))
+	}
+	if withButtons {
+		if g.passing {
+			waiting := []string{}
+			for i, seat := range g.seats {
+				if seat.passing == nil {
+					waiting = append(waiting, g.seatName(i))
+				}
+			}
+			description.WriteString(fmt.Sprintf("\nPick three cards to pass %s. Waiting for %s. Press **My hand** to see your cards.",
+				heartsPassNames[g.passDirection()], strings.Join(waiting, ", ")))
+		} else {
+			description.WriteString(fmt.Sprintf("\n%s to play. Press **My hand** to see your cards and play.", g.seatName(g.turn)))
+		}
+	}
+
+	heartsStatus := "Hearts not broken yet"
+	if g.heartsBroken {
+		heartsStatus = "Hearts broken"
+	}
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Hearts: Hand #%d", g.handNumber),
+		Description: description.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s • Trick %d of %d • The game ends at %d points", heartsStatus, minInt(g.tricksPlayed+1, heartsTricks), heartsTricks, HeartsEndScore),
+		},
+	}
+	if len(g.trick) > 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(g.trick, g.cardsStyle, LayoutRow)}
+	} else if len(g.lastTrick) > 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(g.lastTrick, g.cardsStyle, LayoutRow)}
+	}
+	if !withButtons {
+		return embed, []discordgo.MessageComponent{}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "My hand", Style: discordgo.SecondaryButton, CustomID: "hearts:hand", Emoji: discordgo.ComponentEmoji{Name: "♥️"}},
+			},
+		},
+	}
+}
+
+// resultsEmbed shows the points each seat took in the last hand, and the total scores
+func (g *HeartsGame) resultsEmbed() *discordgo.MessageEmbed {
+	var description strings.Builder
+	for i, seat := range g.seats {
+		description.WriteString(fmt.Sprintf("%s: +%d, %s in total\n", g.seatName(i), g.lastPoints[i], pointsText(seat.score)))
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Hand #%d results", g.handNumber),
+		Description: description.String(),
+	}
+}
+
+// handPanel returns the message showing a player their hand, with the menu to pick the cards to pass or the card to play
+func (g *HeartsGame) handPanel(userID string) *discordgo.InteractionResponseData {
+	i := g.seatIndex(userID)
+	seat := g.seats[i]
+	seat.hand.SortBySuit(playingcards.AceHigh)
+	cards := seat.hand.Cards()
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Your hand (%d cards)", len(cards)),
+		Description: cardNames(cards),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s this hand, %d in total", pointsText(seat.taken), seat.score),
+		},
+	}
+	if len(cards) == 0 {
+		embed.Description = "You have no cards."
+	} else {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(cards, g.cardsStyle, LayoutFan)}
+	}
+	data := &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{},
+		Flags:      discordgo.MessageFlagsEphemeral,
+	}
+	if g.over || len(cards) == 0 {
+		return data
+	}
+
+	if g.passing {
+		if seat.passing != nil {
+			data.Content = fmt.Sprintf("You are passing the %s to %s. Waiting for the other players.",
+				cardNames(seat.passing), g.seatName(g.passTarget(i)))
+			return data
+		}
+		options := make([]discordgo.SelectMenuOption, len(cards))
+		for n, card := range cards {
+			options[n] = discordgo.SelectMenuOption{Label: card.String(), Value: card.Short()}
+		}
+		data.Content = fmt.Sprintf("Pick three cards to pass to %s.", g.seatName(g.passTarget(i)))
+		minValues := heartsPassCount
+		data.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{CustomID: "hearts:pass", Placeholder: "Cards to pass", MinValues: &minValues, MaxValues: heartsPassCount, Options: options},
+				},
+			},
+		}
+		return data
+	}
+
+	if g.turn != i {
+		data.Content = fmt.Sprintf("It's %s's turn. Press **My hand** again to refresh your cards.", g.seatName(g.turn))
+		return data
+	}
+	plays := g.legalPlays(i)
+	options := make([]discordgo.SelectMenuOption, len(plays))
+	for n, card := range plays {
+		options[n] = discordgo.SelectMenuOption{Label: card.String(), Value: card.Short()}
+	}
+	data.Content = "It's your turn! Pick a card to play."
+	if len(g.trick) > 0 {
+		data.Content = fmt.Sprintf("It's your turn! The trick so far: %s.", cardNames(g.trick))
+	}
+	data.Components = []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "hearts:play", Placeholder: "Card to play", Options: options},
+			},
+		},
+	}
+	return data
+}
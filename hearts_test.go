@@ -0,0 +1,246 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func heartsCards(t *testing.T, s string) []playingcards.Card {
+	t.Helper()
+	cards, err := playingcards.ParseCards(s)
+	if err != nil {
+		t.Fatalf("ParseCards(%q): %v", s, err)
+	}
+	return cards
+}
+
+// heartsBotGame returns a game past its first trick where seat 1, a bot, holds hand and is to play to trick
+func heartsBotGame(t *testing.T, hand string, trick string, heartsBroken bool) *HeartsGame {
+	t.Helper()
+	g := NewHeartsGame("guild", "channel", 0, &playingcards.Deck{})
+	for i := range g.seats {
+		g.seats[i] = &heartsSeat{}
+	}
+	g.seats[1].hand = playingcards.NewHand(heartsCards(t, hand)...)
+	g.trick = heartsCards(t, trick)
+	g.tricksPlayed = 1
+	g.heartsBroken = heartsBroken
+	return g
+}
+
+func TestBotPass(t *testing.T) {
+	tests := []struct {
+		hand string
+		want string
+	}{
+		// The queen of spades and the spades that could catch her go first
+		{"2C 3C QS AS KS AH KH 4D", "QS AS KS"},
+		{"2C 3C JS AH KH 4D 10S", "AH KH JS"},
+		// Without hearts or high spades, the highest cards go
+		{"2C 3C 4C KD 5D 9S JC", "KD JC 9S"},
+	}
+	for _, test := range tests {
+		hand := playingcards.NewHand(heartsCards(t, test.hand)...)
+		got := cardNames(botPass(hand))
+		if want := cardNames(heartsCards(t, test.want)); got != want {
+			t.Errorf("botPass(%s) = %s, want %s", test.hand, got, want)
+		}
+		if hand.Size() != len(heartsCards(t, test.hand)) {
+			t.Errorf("botPass(%s) changed the hand to %d cards", test.hand, hand.Size())
+		}
+	}
+}
+
+func TestBotPlay(t *testing.T) {
+	tests := []struct {
+		name         string
+		hand         string
+		trick        string
+		heartsBroken bool
+		want         string
+	}{
+		{"leads its lowest card", "KD 3S 9C", "", false, "3S"},
+		{"doesn't lead hearts before they are broken", "2H 5C 9C", "", false, "5C"},
+		{"leads hearts once they are broken", "2H 5C 9C", "", true, "2H"},
+		{"ducks with its highest card under the winner", "4D 9D QD", "10D", false, "9D"},
+		{"ducks under the highest card of the suit led", "4D 9D QD", "3D KD AS", false, "QD"},
+		{"plays high when it takes the trick anyway", "JD QD AD", "10D", false, "AD"},
+		{"keeps the queen of spades when it takes the trick anyway", "QS KS", "2S", false, "KS"},
+		{"throws the queen of spades when it can't follow", "QS AH 2D", "5C", false, "QS"},
+		{"throws high spades before hearts when it can't follow", "3H AH KS 2D", "5C", false, "KS"},
+		{"throws its highest heart without high spades", "3H AH 2D KD", "5C", false, "AH"},
+	}
+	for _, test := range tests {
+		g := heartsBotGame(t, test.hand, test.trick, test.heartsBroken)
+		got := g.botPlay(1)
+		if want := heartsCards(t, test.want)[0]; !got.SameFace(want) {
+			t.Errorf("bot %s: played %s from %s, want %s", test.name, got.Short(), test.hand, want.Short())
+		}
+	}
+}
+
+func TestBotPlayFirstTrick(t *testing.T) {
+	g := heartsBotGame(t, "2C KC QS", "", false)
+	g.tricksPlayed = 0
+	if got := g.botPlay(1); !got.SameFace(twoOfClubs) {
+		t.Errorf("bot led %s to the first trick, want the 2 of Clubs", got.Short())
+	}
+
+	// No points can be thrown on the first trick unless there is no other choice
+	g = heartsBotGame(t, "QS AH 4D", "2C", false)
+	g.tricksPlayed = 0
+	if got := g.botPlay(1); got.Short() != "4D" {
+		t.Errorf("bot threw %s on the first trick, want 4D", got.Short())
+	}
+}
+
+// TestHeartsAbandoned lets every turn time out until the game is abandoned mid-hand,
+// which must end it without hand results or a winner
+func TestHeartsAbandoned(t *testing.T) {
+	f := NewFakeSession()
+	g := NewHeartsGame(testGuild("hearts-idle"), "table", 0, &playingcards.Deck{})
+	for _, userID := range []string{"101", "102", "103", "104"} {
+		g.Join(userID)
+	}
+	g.Start("101")
+	handOver := false
+	for !handOver {
+		var err error
+		if handOver, err = g.Timeout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !g.abandoned || g.tricksPlayed == heartsTricks {
+		t.Fatalf("the hand ended after %d tricks without the game being abandoned", g.tricksPlayed)
+	}
+	g.afterAction(f, true)
+	messages := f.Messages("table")
+	if len(messages) != 1 || !strings.Contains(messages[0].Content, "Nobody has played") {
+		t.Fatalf("abandoning the game sent %d messages, want only the notice that it was stopped", len(messages))
+	}
+	if text := messageText(messages[0].Content, messages[0].Embeds); strings.Contains(text, "results") || strings.Contains(text, "wins") {
+		t.Errorf("abandoning the game sent %q, want no results or winner", text)
+	}
+}
+
+func TestHeartsPassTarget(t *testing.T) {
+	// Each hand passes the other way: left, right, across, then held, and the rotation starts over
+	tests := []struct {
+		handNumber int
+		want       [HeartsSeats]int
+	}{
+		{1, [HeartsSeats]int{1, 2, 3, 0}},
+		{2, [HeartsSeats]int{3, 0, 1, 2}},
+		{3, [HeartsSeats]int{2, 3, 0, 1}},
+		{4, [HeartsSeats]int{0, 1, 2, 3}},
+		{5, [HeartsSeats]int{1, 2, 3, 0}},
+	}
+	for _, test := range tests {
+		g := &HeartsGame{handNumber: test.handNumber}
+		for i, want := range test.want {
+			if got := g.passTarget(i); got != want {
+				t.Errorf("hand %d: seat %d passes to seat %d, want %d", test.handNumber, i, got, want)
+			}
+		}
+	}
+}
+
+func TestHeartsLegalPlays(t *testing.T) {
+	tests := []struct {
+		name         string
+		hand         string
+		trick        string
+		tricksPlayed int
+		heartsBroken bool
+		want         string
+	}{
+		{"the 2 of Clubs leads the first trick", "2C 5C AH QS", "", 0, false, "2C"},
+		{"hearts can't be led before they are broken", "5C AH 3H 9D", "", 3, false, "5C 9D"},
+		{"hearts can be led with nothing else", "AH 3H", "", 3, false, "AH 3H"},
+		{"anything can be led once hearts are broken", "5C AH", "", 3, true, "5C AH"},
+		{"players follow the suit led", "5C 9C AH QS 2D", "3C", 3, false, "5C 9C"},
+		{"anything goes without the suit led", "AH QS 2D", "3C", 3, false, "AH QS 2D"},
+		{"no points on the first trick", "AH QS 2D", "2C", 0, false, "2D"},
+		{"points on the first trick with no other choice", "AH QS 3H", "2C", 0, false, "AH QS 3H"},
+	}
+	for _, test := range tests {
+		g := heartsBotGame(t, test.hand, test.trick, test.heartsBroken)
+		g.tricksPlayed = test.tricksPlayed
+		if got, want := cardNames(g.legalPlays(1)), cardNames(heartsCards(t, test.want)); got != want {
+			t.Errorf("%s: legal plays are %s, want %s", test.name, got, want)
+		}
+	}
+}
+
+func TestHeartsFinishHand(t *testing.T) {
+	tests := []struct {
+		name       string
+		scores     [HeartsSeats]int
+		taken      [HeartsSeats]int
+		wantScores [HeartsSeats]int
+		wantOver   bool
+	}{
+		{"points add up", [HeartsSeats]int{10, 20, 0, 5}, [HeartsSeats]int{13, 5, 8, 0}, [HeartsSeats]int{23, 25, 8, 5}, false},
+		{"shooting the moon gives 26 to everyone else", [HeartsSeats]int{10, 20, 0, 5}, [HeartsSeats]int{0, 26, 0, 0}, [HeartsSeats]int{36, 20, 26, 31}, false},
+		{"the game ends at 100", [HeartsSeats]int{90, 20, 0, 5}, [HeartsSeats]int{10, 16, 0, 0}, [HeartsSeats]int{100, 36, 0, 5}, true},
+		{"shooting the moon can end the game", [HeartsSeats]int{80, 20, 0, 5}, [HeartsSeats]int{0, 0, 26, 0}, [HeartsSeats]int{106, 46, 0, 31}, true},
+	}
+	for _, test := range tests {
+		g := NewHeartsGame("guild", "channel", 0, &playingcards.Deck{})
+		for i := range g.seats {
+			g.seats[i] = &heartsSeat{score: test.scores[i], taken: test.taken[i]}
+		}
+		g.finishHand()
+		for i, seat := range g.seats {
+			if seat.score != test.wantScores[i] {
+				t.Errorf("%s: seat %d has %d points, want %d", test.name, i, seat.score, test.wantScores[i])
+			}
+		}
+		if g.over != test.wantOver {
+			t.Errorf("%s: over is %v, want %v", test.name, g.over, test.wantOver)
+		}
+	}
+}
+
+func TestHeartsWinners(t *testing.T) {
+	g := NewHeartsGame("guild", "channel", 0, &playingcards.Deck{})
+	for i, score := range []int{104, 40, 62, 40} {
+		g.seats[i] = &heartsSeat{score: score}
+	}
+	if winners := g.winners(); len(winners) != 2 || winners[0] != 1 || winners[1] != 3 {
+		t.Errorf("winners are seats %v, want the two lowest scores, seats 1 and 3", winners)
+	}
+}
+
+// TestHeartsMenus passes and plays cards in Hearts through the select menus of the hand panel
+func TestHeartsMenus(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("menus-hearts"), "table"
+	lobby, _ := f.InteractionResponse(f.Command(guildID, channelID, "101", "hearts").Interaction)
+	f.Press(guildID, "101", lobby, "hearts:start")
+	table := lastMessage(t, f, channelID)
+
+	panel := ephemeralMessage(t, f, f.Press(guildID, "101", table, "hearts:hand"))
+	menu := selectMenu(panel, "hearts:pass")
+	if menu == nil {
+		t.Fatalf("the first hand panel has no menu to pass cards: %q", panel.Content)
+	}
+	i := f.Choose(guildID, "101", panel, "hearts:pass", menu.Options[0].Value, menu.Options[1].Value, menu.Options[2].Value)
+	if text := responseText(t, f, i); !strings.Contains(text, "Your hand (13 cards)") {
+		t.Fatalf("passing responded %q", text)
+	}
+
+	// The bots play until it's the player's turn
+	panel = ephemeralMessage(t, f, f.Press(guildID, "101", table, "hearts:hand"))
+	menu = selectMenu(panel, "hearts:play")
+	if menu == nil {
+		t.Fatalf("the hand panel has no menu to play a card: %q", panel.Content)
+	}
+	i = f.Choose(guildID, "101", panel, "hearts:play", menu.Options[0].Value)
+	if text := responseText(t, f, i); !strings.Contains(text, "Your hand (12 cards)") {
+		t.Errorf("playing a card responded %q", text)
+	}
+	f.Command(guildID, channelID, "101", "quit-game")
+}
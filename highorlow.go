@@ -0,0 +1,622 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: HighOrLow,
+		Name: "High or Low",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "high-or-low",
+			Description: "Start a game of High or Low.",
+		},
+		CommandHandler:   highOrLowCommand,
+		ComponentPrefix:  "highorlow",
+		ComponentHandler: highOrLowComponent,
+		MessageCommand:   "high_or_low",
+		MessageHandler:   highOrLowMessage,
+	})
+}
+
+// How long players have to join a game of High or Low, and to pick higher or lower in each round.
+// They are variables so highorlow_test.go can shorten them and the tests play whole games quickly.
+var (
+	highOrLowJoinTime  = 7 * time.Second
+	highOrLowRoundTime = 5 * time.Second
+)
+
+// Constants that represent a player's decision in a High or Low game
+const (
+	NoGuess int = iota
+	High
+	Low
+)
+
+// PlayerState represents a player's state during a game of High or Low
+type PlayerState struct {
+	choice int
+	active bool
+}
+
+// Active returns whether a player is still in the currently running game or not
+func (p PlayerState) Active() bool {
+	return p.active
+}
+
+// HighOrLowGame holds the state of a game of High or Low
+type HighOrLowGame struct {
+	mu      sync.Mutex
+	players map[string]*PlayerState
+	deck    playingcards.Deck
+	card    playingcards.Card
+	started bool
+	closed  bool
+	// messageID is the message whose buttons are currently counted
+	messageID  string
+	numPlayers int
+	numRounds  int
+	// roundMessage describes the outcome of the last round
+	roundMessage string
+	// The deck is shuffled from serverSeed, whose hash seedHash is shown before the game, and clientSeed.
+	// serverSeed is empty if no seed could be made, and the deck is shuffled normally instead.
+	serverSeed string
+	seedHash   string
+	clientSeed string
+	// playerSeeds are the seeds players added while the game was open to join, in the order they were added.
+	// They only become part of the client seed when the game starts, after the hash was posted.
+	playerSeeds []string
+	// dealt lists every card drawn so far, so the shuffle can be verified
+	dealt []playingcards.Card
+}
+
+// NewHighOrLowGame creates a game that players can join. Its server seed is made right away, so its hash can be
+// posted before anyone adds to the client seed. The deck is shuffled provably fairly when the game starts.
+func NewHighOrLowGame() *HighOrLowGame {
+	game := &HighOrLowGame{
+		players: make(map[string]*PlayerState),
+		deck:    newFairDeck(),
+	}
+	serverSeed, err := playingcards.NewServerSeed()
+	if err != nil {
+		log.Println("Error making a server seed,", err)
+		return game
+	}
+	game.serverSeed = serverSeed
+	game.seedHash = playingcards.HashSeed(serverSeed)
+	return game
+}
+
+// Lock locks the game's state
+func (g *HighOrLowGame) Lock() {
+	g.mu.Lock()
+}
+
+// Unlock unlocks the game's state
+func (g *HighOrLowGame) Unlock() {
+	g.mu.Unlock()
+}
+
+// Players returns a list of active (alive) and inactive(dead) players for the game
+func (g *HighOrLowGame) Players() map[string]*PlayerState {
+	return g.players
+}
+
+// Join adds a player before the game starts
+func (g *HighOrLowGame) Join(userID string) error {
+	if g.started {
+		return errors.New("The game has already started.")
+	}
+	if _, ok := g.players[userID]; ok {
+		return errors.New("You already joined the game.")
+	}
+	g.players[userID] = &PlayerState{active: true}
+	return nil
+}
+
+// AddSeed adds a player's own text to the client seed while the game is open to join
+func (g *HighOrLowGame) AddSeed(seed string) error {
+	if g.started {
+		return errors.New("The game has already started, so the deck was already shuffled.")
+	}
+	if g.serverSeed == "" {
+		return errors.New("This game isn't shuffled provably fairly.")
+	}
+	if strings.TrimSpace(seed) == "" {
+		return errors.New("The seed can't be empty.")
+	}
+	g.playerSeeds = append(g.playerSeeds, strings.TrimSpace(seed))
+	return nil
+}
+
+// Start draws the first card once the joining time is over. The client seed is made from the IDs of the players,
+// in order and separated by commas, followed by the seeds they added, each after a "|".
+func (g *HighOrLowGame) Start(userID string) (bool, error) {
+	g.started = true
+	if len(g.players) == 0 {
+		return true, errors.New("Nobody joined!")
+	}
+	if g.serverSeed == "" {
+		g.deck.Shuffle()
+	} else {
+		playerIDs := make([]string, 0, len(g.players))
+		for player := range g.players {
+			playerIDs = append(playerIDs, player)
+		}
+		sort.Strings(playerIDs)
+		g.clientSeed = strings.Join(append([]string{strings.Join(playerIDs, ",")}, g.playerSeeds...), "|")
+		g.deck.ShuffleWithSeeds(g.serverSeed, g.clientSeed)
+	}
+	g.drawCard()
+	g.numPlayers = len(g.players)
+	return false, nil
+}
+
+// drawCard draws the next card of the game and remembers it
+func (g *HighOrLowGame) drawCard() {
+	g.card = g.deck.DrawCard()
+	g.dealt = append(g.dealt, g.card)
+}
+
+// Proof returns what is needed to verify the game's shuffle, or nil if it wasn't provably fair
+func (g *HighOrLowGame) Proof() *ShuffleProof {
+	if g.serverSeed == "" {
+		return nil
+	}
+	return &ShuffleProof{
+		SeedHash:   g.seedHash,
+		ServerSeed: g.serverSeed,
+		ClientSeed: g.clientSeed,
+		Cards:      append([]playingcards.Card{}, g.dealt...),
+	}
+}
+
+// Action records a player's "high" or "low" guess for the current round. Only the first guess of each round counts.
+func (g *HighOrLowGame) Action(userID string, action string, amount int) (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	guess := NoGuess
+	if action == "high" {
+		guess = High
+	} else if action == "low" {
+		guess = Low
+	} else {
+		return false, errors.New("Unknown button.")
+	}
+	playerState, ok := g.players[userID]
+	if !ok {
+		return false, errors.New("You are not playing in this game.")
+	}
+	if !playerState.Active() {
+		return false, errors.New("You have already been eliminated.")
+	}
+	if playerState.choice != NoGuess {
+		return false, errors.New("You already made your pick this round.")
+	}
+	playerState.choice = guess
+	return false, nil
+}
+
+// Timeout ends the current round by drawing the next card and eliminating the players who guessed wrong.
+// It returns true once the game is over.
+func (g *HighOrLowGame) Timeout() (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	lastCardValue := g.card.Value()
+	g.drawCard()
+	correctGuess := NoGuess // Default is a tie
+	guessString := ""
+	if g.card.Value() < lastCardValue {
+		correctGuess = Low
+		guessString = "lower"
+	} else if g.card.Value() > lastCardValue {
+		correctGuess = High
+		guessString = "higher"
+	}
+
+	var roundMessage strings.Builder
+	if correctGuess == NoGuess {
+		// The new card was neither higher nor lower, nobody is eliminated
+		roundMessage.WriteString("Draw! Nobody was eliminated.")
+		for _, playerState := range g.players {
+			// Make sure to reset the players' choices
+			if playerState.Active() {
+				playerState.choice = NoGuess
+			}
+		}
+	} else {
+		eliminatedPlayers := []string{}
+		// Iterate through all active players, removing those who made the wrong guess
+		for player, playerState := range g.players {
+			if playerState.Active() && playerState.choice != correctGuess {
+				playerState.active = false
+				eliminatedPlayers = append(eliminatedPlayers, player)
+			}
+			// Make sure to reset the player's choice
+			playerState.choice = NoGuess
+		}
+		noMorePlayers := len(eliminatedPlayers) >= g.numPlayers
+		// List the players eliminated this round
+		roundMessage.WriteString(fmt.Sprintf("%s. The next card was %s!\n", g.card.String(), guessString))
+		if len(eliminatedPlayers) == 0 {
+			roundMessage.WriteString("No players eliminated.")
+		} else {
+			roundMessage.WriteString("Players eliminated this round: ")
+			for _, player := range eliminatedPlayers {
+				// If these players were the last ones eliminated, revert their active status (making them winners)
+				if noMorePlayers {
+					g.players[player].active = true
+				}
+				roundMessage.WriteString(fmt.Sprintf("%s ", mention(player)))
+			}
+		}
+
+		g.numPlayers -= len(eliminatedPlayers)
+	}
+	g.roundMessage = roundMessage.String()
+
+	if g.numPlayers <= 0 {
+		return true, nil
+	}
+	g.numRounds++
+	// The game also ends when it runs out of cards
+	return g.deck.Size() == 0, nil
+}
+
+// End stops the game
+func (g *HighOrLowGame) End() {
+	g.closed = true
+}
+
+// Discord side of the game
+
+// highOrLowCommand handles the /high-or-low slash command
+func highOrLowCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	game := reserveHighOrLow(state, i.ChannelID)
+	if game == nil {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+	embed, components := highOrLowJoinMessage(game)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		cancelHighOrLow(state, i.ChannelID, game)
+		return
+	}
+	messageObj, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		cancelHighOrLow(state, i.ChannelID, game)
+		s.ChannelMessageSend(i.ChannelID, "Error when trying to start the game.")
+		return
+	}
+	startHighOrLow(game, s, i.GuildID, i.ChannelID, messageObj.ID)
+}
+
+// highOrLowMessage handles the old "$pcb high_or_low" text command
+func highOrLowMessage(s Session, m *discordgo.MessageCreate) {
+	state := GetServerState(m.GuildID)
+	game := reserveHighOrLow(state, m.ChannelID)
+	if game == nil {
+		s.ChannelMessageSend(m.ChannelID, gameInProgressWarning())
+		return
+	}
+	embed, components := highOrLowJoinMessage(game)
+	messageObj, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		cancelHighOrLow(state, m.ChannelID, game)
+		s.ChannelMessageSend(m.ChannelID, "Error when trying to start the game.")
+		return
+	}
+	startHighOrLow(game, s, m.GuildID, m.ChannelID, messageObj.ID)
+}
+
+// highOrLowJoinMessage returns the message that lets players join a new game, with the hash of its server seed
+func highOrLowJoinMessage(game *HighOrLowGame) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	description := "Guess whether the next card will be higher or lower.\nPress **Join** to play.\nOnly your first pick in each round will be counted, so choose carefully!"
+	if game.seedHash != "" {
+		description += fmt.Sprintf("\n\nThe deck will be shuffled provably fairly. Hash of the server seed: `%s`\n"+
+			"Press **Add seed** to mix your own text into the shuffle before the game starts.", game.seedHash)
+	}
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "High or Low",
+		Description: description,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Game starting in %d seconds...", int(highOrLowJoinTime.Seconds())),
+		},
+	}
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "highorlow:join", Emoji: discordgo.ComponentEmoji{Name: "🎲"}},
+	}
+	if game.seedHash != "" {
+		buttons = append(buttons, discordgo.Button{Label: "Add seed", Style: discordgo.SecondaryButton, CustomID: "highorlow:seed", Emoji: discordgo.ComponentEmoji{Name: "🌱"}})
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+	return embed, components
+}
+
+func highOrLowGuessComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Higher", Style: discordgo.PrimaryButton, CustomID: "highorlow:high", Emoji: discordgo.ComponentEmoji{Name: "⬆️"}},
+				discordgo.Button{Label: "Lower", Style: discordgo.PrimaryButton, CustomID: "highorlow:low", Emoji: discordgo.ComponentEmoji{Name: "⬇️"}},
+			},
+		},
+	}
+}
+
+// clearComponents removes the buttons from a message once they should no longer be pressed
+func clearComponents(s Session, channelID string, messageID string) {
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         messageID,
+		Channel:    channelID,
+		Components: []discordgo.MessageComponent{},
+	})
+}
+
+// reserveHighOrLow sets up a new game of High or Low that players can join, unless a game is already running.
+// It returns nil if the channel is busy with another game.
+func reserveHighOrLow(state *ServerState, channelID string) *HighOrLowGame {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(channelID)
+	if channel.GameType() != NoGame {
+		return nil
+	}
+	game := NewHighOrLowGame()
+	channel.game = GameState{gameType: HighOrLow, current: game}
+	state.save()
+	return game
+}
+
+// cancelHighOrLow undoes reserveHighOrLow when the join message couldn't be sent
+func cancelHighOrLow(state *ServerState, channelID string, game *HighOrLowGame) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(channelID)
+	if channel.game.current == game {
+		resetState(state, channel)
+	}
+}
+
+// startHighOrLow runs a reserved game of High or Low once its join message has been sent
+func startHighOrLow(game *HighOrLowGame, s Session, guildID string, channelID string, joinMessageID string) {
+	game.Lock()
+	game.messageID = joinMessageID
+	game.Unlock()
+	go runHighOrLow(game, s, guildID, channelID)
+}
+
+// highOrLowComponent handles the join, seed and higher/lower buttons of a High or Low game, and the seed modal
+func highOrLowComponent(s Session, i *discordgo.InteractionCreate) {
+	game, _ := runningGame(i, HighOrLow).(*HighOrLowGame)
+	if game == nil {
+		respondEphemeral(s, i, "This game is already over.")
+		return
+	}
+	userID := interactionUserID(i)
+	if i.Type == discordgo.InteractionModalSubmit {
+		game.Lock()
+		err := game.AddSeed(modalTextValue(i, "seed"))
+		game.Unlock()
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		respondEphemeral(s, i, "Your seed will be mixed into the shuffle.")
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if customID == "highorlow:seed" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: "highorlow:seed_text",
+				Title:    "Add to the client seed",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{
+						Components: []discordgo.MessageComponent{
+							discordgo.TextInput{
+								CustomID:  "seed",
+								Label:     "Any text, like a few random words",
+								Style:     discordgo.TextInputShort,
+								Required:  true,
+								MaxLength: 64,
+							},
+						},
+					},
+				},
+			},
+		})
+		return
+	}
+
+	game.Lock()
+	msg := highOrLowPress(game, i, userID, customID)
+	game.Unlock()
+	respondEphemeral(s, i, msg)
+}
+
+// highOrLowPress applies a button press to the game and returns the confirmation for the player. The game must be locked.
+func highOrLowPress(game *HighOrLowGame, i *discordgo.InteractionCreate, userID string, customID string) string {
+	if game.closed {
+		return "This game is already over."
+	}
+	if customID == "highorlow:join" {
+		if err := game.Join(userID); err != nil {
+			return err.Error()
+		}
+		return "You joined the game!"
+	}
+	if i.Message == nil || game.messageID != i.Message.ID {
+		return "This round is already over."
+	}
+	action := strings.TrimPrefix(customID, "highorlow:")
+	if _, err := game.Action(userID, action, 0); err != nil {
+		return err.Error()
+	}
+	if action == "high" {
+		return "You picked higher."
+	}
+	return "You picked lower."
+}
+
+// runHighOrLow runs a game of High or Low in the channel the bot responded to.
+// The game must already be set up by startHighOrLow.
+func runHighOrLow(game *HighOrLowGame, s Session, guildID string, channelID string) {
+	game.Lock()
+	messageID := game.messageID
+	game.Unlock()
+
+	time.Sleep(highOrLowJoinTime)
+	clearComponents(s, channelID, messageID)
+
+	game.Lock()
+	if game.closed {
+		game.Unlock()
+		return
+	}
+	if _, err := game.Start(""); err != nil {
+		game.End()
+		game.Unlock()
+		endGame(guildID, channelID, game)
+		s.ChannelMessageSend(channelID, err.Error())
+		return
+	}
+	game.Unlock()
+
+	// Game loop
+	for {
+		// Show the current card with the higher/lower buttons, then wait 5 seconds
+		style := serverCardsStyle(guildID)
+		game.Lock()
+		if game.closed {
+			game.Unlock()
+			return
+		}
+		message := &discordgo.MessageEmbed{
+			Color: 0x3dbb6b,
+			Title: game.card.String(),
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("%d cards remaining.", game.deck.Size()),
+			},
+			Image: &discordgo.MessageEmbedImage{
+				URL: GetCardURL(game.card, style),
+			},
+		}
+		game.Unlock()
+		messageObj, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{message},
+			Components: highOrLowGuessComponents(),
+		})
+		game.Lock()
+		if game.closed {
+			game.Unlock()
+			return
+		}
+		if err != nil {
+			// Something went wrong, stop the game
+			game.End()
+			game.Unlock()
+			endGame(guildID, channelID, game)
+			s.ChannelMessageSend(channelID, "Error found while running the game. Exiting...")
+			return
+		}
+		game.messageID = messageObj.ID
+		game.Unlock()
+
+		time.Sleep(highOrLowRoundTime)
+		clearComponents(s, channelID, messageObj.ID)
+
+		game.Lock()
+		if game.closed {
+			// The game was stopped
+			game.Unlock()
+			return
+		}
+		gameOver, _ := game.Timeout()
+		roundMessage := game.roundMessage
+		outOfCards := gameOver && game.numPlayers > 0
+		game.Unlock()
+		s.ChannelMessageSend(channelID, roundMessage)
+
+		if outOfCards {
+			// Ran out of cards, end the game
+			s.ChannelMessageSend(channelID, "No more cards left!")
+		}
+		if gameOver {
+			break
+		}
+	}
+
+	style := serverCardsStyle(guildID)
+	game.Lock()
+	if game.closed {
+		game.Unlock()
+		return
+	}
+	// Print the last card of the game
+	message := &discordgo.MessageEmbed{
+		Color: 0x3dbb6b,
+		Title: fmt.Sprintf("Last card drawn: %s", game.card.String()),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d cards remained.", game.deck.Size()),
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: GetCardURL(game.card, style),
+		},
+	}
+
+	// List the winners
+	var winnersMessage strings.Builder
+	roundString := "rounds"
+	if game.numRounds == 1 {
+		roundString = "round"
+	}
+	winnersMessage.WriteString(fmt.Sprintf("Game end! Congrats to the following players who lasted the most rounds! (%d %s)\n", game.numRounds, roundString))
+	for player, playerState := range game.Players() {
+		if playerState.Active() {
+			winnersMessage.WriteString(fmt.Sprintf("%s ", mention(player)))
+		}
+	}
+
+	proof := game.Proof()
+
+	// Reset game state
+	game.End()
+	game.Unlock()
+	endGame(guildID, channelID, game)
+
+	s.ChannelMessageSendEmbed(channelID, message)
+	s.ChannelMessageSend(channelID, winnersMessage.String())
+	if proof != nil {
+		// Reveal the seeds now that the game is over
+		recordShuffleProof(guildID, channelID, proof)
+		s.ChannelMessageSendEmbed(channelID, proof.revealMessage())
+	}
+}
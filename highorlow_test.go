@@ -0,0 +1,9 @@
+package main
+
+import "time"
+
+func init() {
+	// Play games of High or Low in a fraction of a second
+	highOrLowJoinTime = 50 * time.Millisecond
+	highOrLowRoundTime = 50 * time.Millisecond
+}
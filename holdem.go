@@ -0,0 +1,1002 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: TexasHoldem,
+		Name: "Texas Hold'em",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "holdem",
+			Description: "Start a game of No-Limit Texas Hold'em.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "chips",
+					Description: fmt.Sprintf("How many chips each player starts with (default %d)", DefaultHoldemChips),
+					MinValue:    &integerOptionMinValue,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "big-blind",
+					Description: fmt.Sprintf("The size of the big blind (default %d)", DefaultHoldemBigBlind),
+					MinValue:    &bigBlindOptionMinValue,
+				},
+			},
+		},
+		CommandHandler:   holdemCommand,
+		ComponentPrefix:  "holdem",
+		ComponentHandler: holdemComponent,
+	})
+}
+
+// Defaults and limits for Texas Hold'em tables
+const (
+	DefaultHoldemChips    = 1000
+	DefaultHoldemBigBlind = 20
+	MaxHoldemPlayers      = 10
+	holdemTurnTimeout     = 60 * time.Second
+	holdemNextHandDelay   = 8 * time.Second
+	holdemLogLength       = 5
+)
+
+// Streets (betting rounds) of a hand of Texas Hold'em
+const (
+	preflop int = iota
+	flop
+	turn
+	river
+)
+
+var holdemStreetNames = []string{"Pre-flop", "Flop", "Turn", "River"}
+
+// Actions a player can take on their turn
+const (
+	holdemFold int = iota
+	holdemCheck
+	holdemCall
+	holdemRaise
+	holdemAllIn
+)
+
+// holdemSeat is a player sitting at a Texas Hold'em table
+type holdemSeat struct {
+	userID    string
+	chips     int
+	holeCards []playingcards.Card
+	// bet is how much the player has put in during the current betting round
+	bet int
+	// contributed is how much the player has put in during the whole hand
+	contributed int
+	folded      bool
+	allIn       bool
+	// acted is whether the player has acted since the last full raise
+	acted bool
+	// out is set once a player has lost all of their chips
+	out bool
+}
+
+func (p *holdemSeat) inHand() bool {
+	return !p.out && !p.folded
+}
+
+func (p *holdemSeat) canAct() bool {
+	return p.inHand() && !p.allIn
+}
+
+// HoldemTable holds the state of a game of No-Limit Texas Hold'em
+type HoldemTable struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+
+	seats         []*holdemSeat
+	startingChips int
+	smallBlind    int
+	bigBlind      int
+	started       bool
+	closed        bool
+
+	handNumber int
+	dealer     int
+	deck       *playingcards.Deck
+	board      []playingcards.Card
+	street     int
+	toAct      int
+	currentBet int
+	minRaise   int
+	// turnToken changes every time the turn passes, so stale timeouts can be ignored
+	turnToken int
+	log       []string
+}
+
+// NewHoldemTable creates an empty table waiting for players to join, which deals its hands from the given deck
+func NewHoldemTable(guildID string, channelID string, chips int, bigBlind int, cardsStyle int, deck *playingcards.Deck) *HoldemTable {
+	return &HoldemTable{
+		guildID:       guildID,
+		channelID:     channelID,
+		cardsStyle:    cardsStyle,
+		deck:          deck,
+		startingChips: chips,
+		smallBlind:    bigBlind / 2,
+		bigBlind:      bigBlind,
+	}
+}
+
+// Lock locks the table's state
+func (t *HoldemTable) Lock() {
+	t.mu.Lock()
+}
+
+// Unlock unlocks the table's state
+func (t *HoldemTable) Unlock() {
+	t.mu.Unlock()
+}
+
+// End stops the table, so pending timers and button presses are ignored
+func (t *HoldemTable) End() {
+	t.closed = true
+}
+
+func (t *HoldemTable) seatIndex(userID string) int {
+	for i, seat := range t.seats {
+		if seat.userID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Join seats a new player at the table before the game starts
+func (t *HoldemTable) Join(userID string) error {
+	if t.started {
+		return errors.New("The game has already started.")
+	}
+	if t.seatIndex(userID) >= 0 {
+		return errors.New("You are already seated at the table.")
+	}
+	if len(t.seats) >= MaxHoldemPlayers {
+		return errors.New("The table is full.")
+	}
+	t.seats = append(t.seats, &holdemSeat{userID: userID, chips: t.startingChips})
+	return nil
+}
+
+// next returns the index of the first seat after i that matches the given condition, or -1 if there is none
+func (t *HoldemTable) next(i int, match func(*holdemSeat) bool) int {
+	for n := 1; n <= len(t.seats); n++ {
+		j := (i + n) % len(t.seats)
+		if match(t.seats[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+func (t *HoldemTable) count(match func(*holdemSeat) bool) int {
+	n := 0
+	for _, seat := range t.seats {
+		if match(seat) {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *HoldemTable) addLog(format string, a ...interface{}) {
+	t.log = append(t.log, fmt.Sprintf(format, a...))
+	if len(t.log) > holdemLogLength {
+		t.log = t.log[len(t.log)-holdemLogLength:]
+	}
+}
+
+// putIn moves up to amount chips from the player's stack into the pot
+func (t *HoldemTable) putIn(seat *holdemSeat, amount int) int {
+	if amount > seat.chips {
+		amount = seat.chips
+	}
+	seat.chips -= amount
+	seat.bet += amount
+	seat.contributed += amount
+	if seat.chips == 0 {
+		seat.allIn = true
+	}
+	return amount
+}
+
+func (t *HoldemTable) pot() int {
+	total := 0
+	for _, seat := range t.seats {
+		total += seat.contributed
+	}
+	return total
+}
+
+// startHand shuffles a new deck, moves the dealer button, posts the blinds and deals the hole cards.
+// It returns true if the blinds put enough players all-in that the hand is already over.
+func (t *HoldemTable) startHand() bool {
+	for _, seat := range t.seats {
+		if seat.chips == 0 {
+			seat.out = true
+		}
+		seat.holeCards = nil
+		seat.bet = 0
+		seat.contributed = 0
+		seat.folded = false
+		seat.allIn = false
+		seat.acted = false
+	}
+	playing := func(p *holdemSeat) bool { return !p.out }
+	if t.handNumber == 0 {
+		t.dealer = t.next(len(t.seats)-1, playing)
+	} else {
+		t.dealer = t.next(t.dealer, playing)
+	}
+	t.handNumber++
+	*t.deck = newStandardDeck()
+	t.deck.Shuffle()
+	t.board = nil
+	t.street = preflop
+	t.log = nil
+
+	// Heads-up, the dealer posts the small blind
+	smallBlind := t.dealer
+	if t.count(playing) > 2 {
+		smallBlind = t.next(t.dealer, playing)
+	}
+	bigBlind := t.next(smallBlind, playing)
+	t.putIn(t.seats[smallBlind], t.smallBlind)
+	t.putIn(t.seats[bigBlind], t.bigBlind)
+	t.addLog("%s posts the small blind, %s posts the big blind.", mention(t.seats[smallBlind].userID), mention(t.seats[bigBlind].userID))
+	t.currentBet = t.bigBlind
+	t.minRaise = t.bigBlind
+
+	for round := 0; round < 2; round++ {
+		i := t.dealer
+		for n := 0; n < t.count(playing); n++ {
+			i = t.next(i, playing)
+			t.seats[i].holeCards = append(t.seats[i].holeCards, t.deck.DrawCard())
+		}
+	}
+
+	t.toAct = bigBlind
+	return t.passTurn()
+}
+
+// roundComplete returns whether every player who can still act has matched the current bet
+func (t *HoldemTable) roundComplete() bool {
+	canAct := 0
+	allActed := true
+	for _, seat := range t.seats {
+		if !seat.canAct() {
+			continue
+		}
+		canAct++
+		if seat.bet < t.currentBet {
+			return false
+		}
+		if !seat.acted {
+			allActed = false
+		}
+	}
+	// A lone player who has already matched every all-in has nothing left to decide
+	return canAct <= 1 || allActed
+}
+
+// needsToAct returns whether a player still has a decision to make in the current betting round
+func (t *HoldemTable) needsToAct(p *holdemSeat) bool {
+	return p.canAct() && (!p.acted || p.bet < t.currentBet)
+}
+
+// passTurn moves the turn to the next player who can act, moving on to later streets as betting rounds finish.
+// It returns true once the hand is over.
+func (t *HoldemTable) passTurn() bool {
+	t.turnToken++
+	if t.count((*holdemSeat).inHand) == 1 {
+		return true
+	}
+	if !t.roundComplete() {
+		t.toAct = t.next(t.toAct, t.needsToAct)
+		return false
+	}
+	for {
+		if t.street == river {
+			return true
+		}
+		for _, seat := range t.seats {
+			seat.bet = 0
+			seat.acted = false
+		}
+		t.currentBet = 0
+		t.minRaise = t.bigBlind
+		t.street++
+		t.deck.DrawCard() // Burn a card before each street
+		cardsToDeal := 1
+		if t.street == flop {
+			cardsToDeal = 3
+		}
+		for n := 0; n < cardsToDeal; n++ {
+			t.board = append(t.board, t.deck.DrawCard())
+		}
+		// Keep dealing without betting once at most one player can still bet
+		if t.count((*holdemSeat).canAct) > 1 {
+			t.toAct = t.next(t.dealer, (*holdemSeat).canAct)
+			return false
+		}
+	}
+}
+
+// Start begins the game with the seated players and deals the first hand. It returns true if the hand is already over.
+func (t *HoldemTable) Start(userID string) (bool, error) {
+	if t.started {
+		return false, errors.New("The game has already started.")
+	}
+	if t.seatIndex(userID) < 0 {
+		return false, errors.New("Only seated players can start the game.")
+	}
+	if len(t.seats) < 2 {
+		return false, errors.New("At least 2 players are needed to start.")
+	}
+	t.started = true
+	return t.startHand(), nil
+}
+
+// holdemActions maps the names of the action buttons to the actions
+var holdemActions = map[string]int{
+	"fold":  holdemFold,
+	"check": holdemCheck,
+	"call":  holdemCall,
+	"raise": holdemRaise,
+	"allin": holdemAllIn,
+}
+
+// Action applies a player's "fold", "check", "call", "raise" or "allin" on their turn.
+// It returns true once the hand is over.
+func (t *HoldemTable) Action(userID string, action string, amount int) (bool, error) {
+	if !t.started || t.handNumber == 0 {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	holdemAction, ok := holdemActions[action]
+	if !ok {
+		return false, errors.New("Unknown action.")
+	}
+	return t.act(userID, holdemAction, amount)
+}
+
+// Timeout checks or folds for the player whose turn it is. It returns true once the hand is over.
+func (t *HoldemTable) Timeout() (bool, error) {
+	if !t.started || t.handNumber == 0 {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	seat := t.seats[t.toAct]
+	action := holdemFold
+	if seat.bet == t.currentBet {
+		action = holdemCheck
+	}
+	t.addLog("%s ran out of time.", mention(seat.userID))
+	return t.act(seat.userID, action, 0)
+}
+
+// act applies a player's action on their turn. The amount is the total bet to raise to, and is only used for raises.
+// It returns true once the hand is over.
+func (t *HoldemTable) act(userID string, action int, amount int) (bool, error) {
+	seat := t.seats[t.toAct]
+	if seat.userID != userID {
+		if t.seatIndex(userID) < 0 {
+			return false, errors.New("You are not seated at this table.")
+		}
+		return false, errors.New("It's not your turn.")
+	}
+	toCall := t.currentBet - seat.bet
+	if action == holdemAllIn {
+		amount = seat.bet + seat.chips
+		if amount > t.currentBet && !seat.acted {
+			action = holdemRaise
+		} else {
+			action = holdemCall
+		}
+	}
+
+	switch action {
+	case holdemFold:
+		seat.folded = true
+		t.addLog("%s folds.", mention(userID))
+	case holdemCheck:
+		if toCall > 0 {
+			return false, fmt.Errorf("You can't check, you need to call %d or fold.", toCall)
+		}
+		t.addLog("%s checks.", mention(userID))
+	case holdemCall:
+		if toCall == 0 {
+			t.addLog("%s checks.", mention(userID))
+		} else {
+			called := t.putIn(seat, toCall)
+			if seat.allIn {
+				t.addLog("%s calls %d and is all-in.", mention(userID), called)
+			} else {
+				t.addLog("%s calls %d.", mention(userID), called)
+			}
+		}
+	case holdemRaise:
+		maxBet := seat.bet + seat.chips
+		if seat.acted {
+			return false, errors.New("Nobody has raised since your last action, you can only call or fold.")
+		}
+		if amount <= t.currentBet {
+			return false, fmt.Errorf("A raise must be to more than the current bet of %d.", t.currentBet)
+		}
+		if amount > maxBet {
+			return false, fmt.Errorf("You only have enough chips to raise to %d.", maxBet)
+		}
+		if amount < t.currentBet+t.minRaise && amount != maxBet {
+			return false, fmt.Errorf("The minimum raise is to %d.", t.currentBet+t.minRaise)
+		}
+		raiseSize := amount - t.currentBet
+		t.putIn(seat, amount-seat.bet)
+		if raiseSize >= t.minRaise {
+			// A full raise reopens the betting for everyone else
+			t.minRaise = raiseSize
+			for _, other := range t.seats {
+				other.acted = false
+			}
+		}
+		t.currentBet = amount
+		if seat.allIn {
+			t.addLog("%s raises to %d and is all-in.", mention(userID), amount)
+		} else {
+			t.addLog("%s raises to %d.", mention(userID), amount)
+		}
+	}
+	seat.acted = true
+	return t.passTurn(), nil
+}
+
+// holdemPotResult describes who won a main or side pot, or which chips went back to the player who put them in
+type holdemPotResult struct {
+	amount  int
+	winners []string
+	hand    string
+	// returned is set for chips nobody else could win, like an uncalled bet, which go back to the player
+	returned bool
+}
+
+// finishHand runs the showdown if needed, hands out the pots and returns the results
+func (t *HoldemTable) finishHand() []holdemPotResult {
+	inHand := t.count((*holdemSeat).inHand)
+	if inHand == 1 {
+		winner := t.seats[t.next(len(t.seats)-1, (*holdemSeat).inHand)]
+		amount := t.pot()
+		winner.chips += amount
+		return []holdemPotResult{{amount: amount, winners: []string{winner.userID}}}
+	}
+
+	ranks := make(map[*holdemSeat]playingcards.PokerRank)
+	for _, seat := range t.seats {
+		if seat.inHand() {
+			cards := append(append([]playingcards.Card{}, seat.holeCards...), t.board...)
+			rank, _ := playingcards.EvaluatePoker(cards)
+			ranks[seat] = rank
+		}
+	}
+
+	// Split the chips into a main pot and side pots, one for each different all-in amount
+	levels := []int{}
+	for _, seat := range t.seats {
+		if seat.contributed > 0 {
+			levels = append(levels, seat.contributed)
+		}
+	}
+	sort.Ints(levels)
+	results := []holdemPotResult{}
+	previous, carried, awarded := 0, 0, 0
+	for _, level := range levels {
+		if level == previous {
+			continue
+		}
+		amount := carried
+		eligible := []*holdemSeat{}
+		for _, seat := range t.seats {
+			amount += minInt(seat.contributed, level) - minInt(seat.contributed, previous)
+			if seat.inHand() && seat.contributed >= level {
+				eligible = append(eligible, seat)
+			}
+		}
+		previous = level
+		if len(eligible) == 0 {
+			carried = amount
+			continue
+		}
+		carried = 0
+		awarded = level
+
+		best := playingcards.PokerRank(0)
+		for _, seat := range eligible {
+			if ranks[seat] > best {
+				best = ranks[seat]
+			}
+		}
+		// Odd chips go to the winners closest to the left of the dealer
+		winners := []*holdemSeat{}
+		for n := 1; n <= len(t.seats); n++ {
+			seat := t.seats[(t.dealer+n)%len(t.seats)]
+			if seat.inHand() && seat.contributed >= level && ranks[seat] == best {
+				winners = append(winners, seat)
+			}
+		}
+		result := holdemPotResult{amount: amount, hand: best.String()}
+		for w, winner := range winners {
+			share := amount / len(winners)
+			if w < amount%len(winners) {
+				share++
+			}
+			winner.chips += share
+			result.winners = append(result.winners, winner.userID)
+		}
+		// Merge side pots won by the same players so the results read naturally
+		if last := len(results) - 1; last >= 0 && strings.Join(results[last].winners, " ") == strings.Join(result.winners, " ") {
+			results[last].amount += result.amount
+		} else {
+			results = append(results, result)
+		}
+	}
+	// Chips above the last level anyone still in the hand could win, like the bet of a player who folded
+	// after raising, go back to whoever put them in
+	if carried > 0 {
+		for _, seat := range t.seats {
+			if returned := seat.contributed - minInt(seat.contributed, awarded); returned > 0 {
+				seat.chips += returned
+				results = append(results, holdemPotResult{amount: returned, winners: []string{seat.userID}, returned: true})
+			}
+		}
+	}
+	return results
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Discord side of the game
+
+// holdemCommand handles the /holdem slash command by opening a new table for players to join
+func holdemCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	chips, bigBlind := DefaultHoldemChips, DefaultHoldemBigBlind
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "chips":
+			chips = int(opt.IntValue())
+		case "big-blind":
+			bigBlind = int(opt.IntValue())
+		}
+	}
+	if chips < bigBlind*2 {
+		respondEphemeral(s, i, "Players need to start with at least two big blinds worth of chips.")
+		return
+	}
+
+	table := NewHoldemTable(i.GuildID, i.ChannelID, chips, bigBlind, state.cardsStyle, channel.setStandardDeck())
+	table.Join(interactionUserID(i))
+	channel.game = GameState{gameType: TexasHoldem, current: table}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{table.lobbyEmbed()},
+			Components: holdemLobbyComponents(),
+		},
+	})
+}
+
+func (t *HoldemTable) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	for _, seat := range t.seats {
+		players.WriteString(fmt.Sprintf("%s\n", mention(seat.userID)))
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "No-Limit Texas Hold'em",
+		Description: fmt.Sprintf("Press **Join** to take a seat, then any seated player can press **Start**.\nStarting chips: %d, blinds: %d/%d", t.startingChips, t.smallBlind, t.bigBlind),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", len(t.seats), MaxHoldemPlayers),
+				Value: players.String(),
+			},
+		},
+	}
+}
+
+func holdemLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "holdem:join"},
+				discordgo.Button{Label: "Start", Style: discordgo.PrimaryButton, CustomID: "holdem:start"},
+			},
+		},
+	}
+}
+
+// holdemComponent handles the buttons and modals of a Texas Hold'em game
+func holdemComponent(s Session, i *discordgo.InteractionCreate) {
+	table, _ := runningGame(i, TexasHoldem).(*HoldemTable)
+	if table == nil {
+		respondEphemeral(s, i, "This game of Texas Hold'em is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	var customID string
+	if i.Type == discordgo.InteractionModalSubmit {
+		customID = i.ModalSubmitData().CustomID
+	} else {
+		customID = i.MessageComponentData().CustomID
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	if table.closed {
+		respondEphemeral(s, i, "This game of Texas Hold'em is no longer running.")
+		return
+	}
+
+	switch customID {
+	case "holdem:join":
+		if err := table.Join(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{table.lobbyEmbed()},
+				Components: holdemLobbyComponents(),
+			},
+		})
+	case "holdem:start":
+		handOver, err := table.Start(userID)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{table.lobbyEmbed()},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		table.postHand(s, handOver)
+	case "holdem:cards":
+		seat := table.seatIndex(userID)
+		if seat < 0 || len(table.seats[seat].holeCards) == 0 {
+			respondEphemeral(s, i, "You don't have any cards in this hand.")
+			return
+		}
+		embeds := []*discordgo.MessageEmbed{}
+		for _, card := range table.seats[seat].holeCards {
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				Color: 0x3dbb6b,
+				Title: card.String(),
+				Image: &discordgo.MessageEmbedImage{URL: GetCardURL(card, table.cardsStyle)},
+			})
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: embeds,
+				Flags:  discordgo.MessageFlagsEphemeral,
+			},
+		})
+	case "holdem:raise":
+		if !table.started || table.handNumber == 0 {
+			respondEphemeral(s, i, "The game hasn't started yet.")
+			return
+		}
+		if table.seats[table.toAct].userID != userID {
+			respondEphemeral(s, i, "It's not your turn.")
+			return
+		}
+		seat := table.seats[table.toAct]
+		minRaise := minInt(table.currentBet+table.minRaise, seat.bet+seat.chips)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: "holdem:raise_amount",
+				Title:    "Raise",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{
+						Components: []discordgo.MessageComponent{
+							discordgo.TextInput{
+								CustomID:    "amount",
+								Label:       "Raise your total bet to",
+								Style:       discordgo.TextInputShort,
+								Placeholder: fmt.Sprintf("%d to %d", minRaise, seat.bet+seat.chips),
+								Required:    true,
+								MaxLength:   10,
+							},
+						},
+					},
+				},
+			},
+		})
+	case "holdem:raise_amount":
+		amount, err := strconv.Atoi(strings.TrimSpace(modalTextValue(i, "amount")))
+		if err != nil {
+			respondEphemeral(s, i, "The raise amount must be a whole number.")
+			return
+		}
+		table.handleAction(s, i, userID, "raise", amount)
+	case "holdem:fold", "holdem:check", "holdem:call", "holdem:allin":
+		table.handleAction(s, i, userID, strings.TrimPrefix(customID, "holdem:"), 0)
+	}
+}
+
+// modalTextValue returns the value of the text input with the given custom ID in a submitted modal
+func modalTextValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, row := range i.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// handleAction applies a player's action and updates the table message. The table must be locked.
+func (t *HoldemTable) handleAction(s Session, i *discordgo.InteractionCreate, userID string, action string, amount int) {
+	handOver, err := t.Action(userID, action, amount)
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	t.afterAction(s, handOver)
+}
+
+// afterAction refreshes the table message and either waits for the next player or wraps up the hand
+func (t *HoldemTable) afterAction(s Session, handOver bool) {
+	if !handOver {
+		t.updateMessage(s, true)
+		t.scheduleTimeout(s)
+		return
+	}
+	t.updateMessage(s, false)
+	results := t.finishHand()
+	s.ChannelMessageSendEmbed(t.channelID, t.resultsEmbed(results))
+
+	remaining := t.count(func(p *holdemSeat) bool { return p.chips > 0 })
+	if remaining <= 1 {
+		winner := t.seats[t.next(len(t.seats)-1, func(p *holdemSeat) bool { return p.chips > 0 })]
+		s.ChannelMessageSend(t.channelID, fmt.Sprintf("Game end! %s wins the game with %d chips!", mention(winner.userID), winner.chips))
+		t.end()
+		return
+	}
+
+	go func() {
+		time.Sleep(holdemNextHandDelay)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if !t.closed {
+			t.dealHand(s)
+		}
+	}()
+}
+
+// end stops the game and resets its channel. The table must be locked.
+func (t *HoldemTable) end() {
+	t.End()
+	endGame(t.guildID, t.channelID, t)
+}
+
+// dealHand starts a new hand and posts a new table message for it. The table must be locked.
+func (t *HoldemTable) dealHand(s Session) {
+	t.postHand(s, t.startHand())
+}
+
+// postHand posts a new table message for the hand that was just dealt. The table must be locked.
+func (t *HoldemTable) postHand(s Session, handOver bool) {
+	embeds, components := t.render(!handOver)
+	message, err := s.ChannelMessageSendComplex(t.channelID, &discordgo.MessageSend{
+		Embeds:     embeds,
+		Components: components,
+	})
+	if err != nil {
+		s.ChannelMessageSend(t.channelID, "Error found while running the game. Exiting...")
+		t.end()
+		return
+	}
+	t.messageID = message.ID
+	t.afterAction(s, handOver)
+}
+
+// scheduleTimeout checks or folds for the current player if they take too long. The table must be locked.
+func (t *HoldemTable) scheduleTimeout(s Session) {
+	token := t.turnToken
+	time.AfterFunc(holdemTurnTimeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.closed || t.turnToken != token {
+			return
+		}
+		handOver, err := t.Timeout()
+		if err != nil {
+			return
+		}
+		t.afterAction(s, handOver)
+	})
+}
+
+// updateMessage edits the table message to show the current state of the hand. The table must be locked.
+func (t *HoldemTable) updateMessage(s Session, withButtons bool) {
+	embeds, components := t.render(withButtons)
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         t.messageID,
+		Channel:    t.channelID,
+		Embeds:     embeds,
+		Components: components,
+	})
+}
+
+// render builds the table message: a summary of the hand followed by one embed per community card
+func (t *HoldemTable) render(withButtons bool) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var seats strings.Builder
+	for i, seat := range t.seats {
+		if seat.out {
+			continue
+		}
+		marker := "▫️"
+		if withButtons && i == t.toAct {
+			marker = "▶️"
+		}
+		status := ""
+		if i == t.dealer {
+			status += " (D)"
+		}
+		if seat.folded {
+			status += " folded"
+		} else if seat.allIn {
+			status += " all-in"
+		}
+		seats.WriteString(fmt.Sprintf("%s %s%s: %d chips, bet %d\n", marker, mention(seat.userID), status, seat.chips, seat.bet))
+	}
+
+	description := fmt.Sprintf("**%s**, pot: %d\n\n%s", holdemStreetNames[t.street], t.pot(), seats.String())
+	if len(t.log) > 0 {
+		description += "\n" + strings.Join(t.log, "\n")
+	}
+	if withButtons {
+		description += fmt.Sprintf("\n\n%s to act. Press **My cards** to see your hole cards.", mention(t.seats[t.toAct].userID))
+	}
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Color:       0x3dbb6b,
+			Title:       fmt.Sprintf("Texas Hold'em: Hand #%d", t.handNumber),
+			Description: description,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Blinds %d/%d", t.smallBlind, t.bigBlind),
+			},
+		},
+	}
+	for _, card := range t.board {
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			Color: 0x3dbb6b,
+			Title: card.String(),
+			Image: &discordgo.MessageEmbedImage{URL: GetCardURL(card, t.cardsStyle)},
+		})
+	}
+
+	components := []discordgo.MessageComponent{}
+	if withButtons {
+		seat := t.seats[t.toAct]
+		toCall := t.currentBet - seat.bet
+		callButton := discordgo.Button{Label: "Check", Style: discordgo.SecondaryButton, CustomID: "holdem:check"}
+		if toCall > 0 {
+			callButton = discordgo.Button{Label: fmt.Sprintf("Call %d", minInt(toCall, seat.chips)), Style: discordgo.SuccessButton, CustomID: "holdem:call"}
+		}
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					callButton,
+					discordgo.Button{Label: "Raise", Style: discordgo.PrimaryButton, CustomID: "holdem:raise"},
+					discordgo.Button{Label: "All-in", Style: discordgo.PrimaryButton, CustomID: "holdem:allin"},
+					discordgo.Button{Label: "Fold", Style: discordgo.DangerButton, CustomID: "holdem:fold"},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "My cards", Style: discordgo.SecondaryButton, CustomID: "holdem:cards"},
+				},
+			},
+		}
+	}
+	return embeds, components
+}
+
+// resultsEmbed shows who won each pot, and the hole cards of everyone who went to showdown
+func (t *HoldemTable) resultsEmbed(results []holdemPotResult) *discordgo.MessageEmbed {
+	var description strings.Builder
+	if t.count((*holdemSeat).inHand) > 1 {
+		for _, seat := range t.seats {
+			if !seat.inHand() {
+				continue
+			}
+			cards := append(append([]playingcards.Card{}, seat.holeCards...), t.board...)
+			_, rank, _ := playingcards.BestPokerHand(cards)
+			description.WriteString(fmt.Sprintf("%s shows %s and %s: %s\n", mention(seat.userID), seat.holeCards[0], seat.holeCards[1], rank))
+		}
+		description.WriteString("\n")
+	}
+	pots := 0
+	for _, result := range results {
+		if !result.returned {
+			pots++
+		}
+	}
+	n := 0
+	for _, result := range results {
+		if result.returned {
+			description.WriteString(fmt.Sprintf("%d uncalled chips go back to %s.\n", result.amount, mention(result.winners[0])))
+			continue
+		}
+		potName := "The pot"
+		if pots > 1 {
+			if n == 0 {
+				potName = "The main pot"
+			} else {
+				potName = fmt.Sprintf("Side pot #%d", n)
+			}
+		}
+		n++
+		winners := make([]string, len(result.winners))
+		for w, winner := range result.winners {
+			winners[w] = mention(winner)
+		}
+		if len(winners) > 1 {
+			description.WriteString(fmt.Sprintf("%s of %d is split between %s", potName, result.amount, strings.Join(winners, ", ")))
+		} else {
+			description.WriteString(fmt.Sprintf("%s of %d goes to %s", potName, result.amount, winners[0]))
+		}
+		if result.hand != "" {
+			description.WriteString(fmt.Sprintf(" with %s", result.hand))
+		}
+		description.WriteString(".\n")
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       fmt.Sprintf("Hand #%d results", t.handNumber),
+		Description: description.String(),
+	}
+}
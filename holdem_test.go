@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// holdemChips returns every chip at the table, in the players' stacks and in the pot
+func holdemChips(t *HoldemTable) int {
+	total := 0
+	for _, seat := range t.seats {
+		total += seat.chips + seat.contributed
+	}
+	return total
+}
+
+func TestHoldemFoldedOverbetGoesBack(t *testing.T) {
+	table := NewHoldemTable("guild", "channel", 1000, 20, 0, &playingcards.Deck{})
+	for _, userID := range []string{"a", "b", "c"} {
+		table.Join(userID)
+	}
+	table.Start("a")
+	table.board = table.deck.Cards()[:5]
+	// a put in more than anyone else and then folded, so nobody still in the hand can win the top of the pot
+	for i, contributed := range []int{600, 300, 300} {
+		seat := table.seats[i]
+		seat.chips = 1000 - contributed
+		seat.contributed = contributed
+		seat.folded = i == 0
+	}
+
+	results := table.finishHand()
+	if total := holdemChips(table) - table.pot(); total != 3000 {
+		t.Errorf("%d chips left after the hand, want 3000 (results %+v)", total, results)
+	}
+	if table.seats[0].chips != 700 {
+		t.Errorf("the folded player has %d chips, want their 300 uncalled chips back for 700", table.seats[0].chips)
+	}
+}
+
+// TestHoldemChipConservation plays random hands and checks that chips are only ever moved between players
+func TestHoldemChipConservation(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	actions := []string{"fold", "check", "call", "raise", "allin"}
+	for game := 0; game < 200; game++ {
+		players := 2 + random.Intn(5)
+		table := NewHoldemTable("guild", "channel", 100+random.Intn(900), 20, 0, &playingcards.Deck{})
+		for p := 0; p < players; p++ {
+			table.Join(string(rune('a' + p)))
+		}
+		total := table.startingChips * players
+		handOver, err := table.Start("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for hand := 0; hand < 50 && table.count(func(p *holdemSeat) bool { return !p.out && p.chips > 0 }) > 1; hand++ {
+			for moves := 0; !handOver; moves++ {
+				if moves > 1000 {
+					t.Fatal("hand did not finish")
+				}
+				seat := table.seats[table.toAct]
+				action := actions[random.Intn(len(actions))]
+				amount := table.currentBet + table.minRaise + random.Intn(seat.chips+1)
+				if handOver, err = table.Action(seat.userID, action, amount); err != nil {
+					handOver, err = table.Timeout()
+				}
+				if got := holdemChips(table); got != total {
+					t.Fatalf("game %d hand %d: %d chips at the table mid-hand, want %d", game, hand, got, total)
+				}
+			}
+			table.finishHand()
+			stacks := 0
+			for _, seat := range table.seats {
+				stacks += seat.chips
+			}
+			if stacks != total {
+				t.Fatalf("game %d hand %d: %d chips after paying the pots, want %d", game, hand, stacks, total)
+			}
+			handOver = table.startHand()
+		}
+	}
+}
+
+func TestHoldemDealsFromGivenDeck(t *testing.T) {
+	deck := playingcards.Deck{}
+	table := NewHoldemTable("guild", "channel", 1000, 20, 0, &deck)
+	for _, userID := range []string{"a", "b", "c"} {
+		table.Join(userID)
+	}
+	if _, err := table.Start("a"); err != nil {
+		t.Fatal(err)
+	}
+	if deck.Size() != 52-3*2 || len(deck.Drawn()) != 3*2 {
+		t.Errorf("the deck has %d cards left and %d drawn after dealing the hole cards, want 46 and 6", deck.Size(), len(deck.Drawn()))
+	}
+}
@@ -0,0 +1,1134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: Klondike,
+		Name: "Klondike",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "klondike",
+			Description: "Play Klondike Solitaire, or pick up your game where you left it.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "draw",
+					Description: "How many cards are turned from the stock at a time (default 1)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Draw 1", Value: 1},
+						{Name: "Draw 3", Value: 3},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "new",
+					Description: "Start a new game even if you have one in progress",
+				},
+			},
+		},
+		CommandHandler:   klondikeCommand,
+		ComponentPrefix:  "klondike",
+		ComponentHandler: klondikeComponent,
+		ExtraCommands: []GameCommand{
+			{
+				Command: &discordgo.ApplicationCommand{
+					Name:        "move",
+					Description: "Move cards in your game of Klondike, like from:w to:f or from:3 to:5.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "from",
+							Description: "The pile to move from: w for the waste, a column from 1 to 7, or f and a suit like fH",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "to",
+							Description: "The pile to move to: f for the foundations, or a column from 1 to 7",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "count",
+							Description: "How many cards to move between columns (default: as many as fit)",
+							MinValue:    &integerOptionMinValue,
+						},
+					},
+				},
+				Handler: klondikeMoveCommand,
+			},
+		},
+	})
+}
+
+// Limits for games of Klondike
+const (
+	klondikeColumns = 7
+	// klondikeUndoLimit is how many moves can be undone in a row
+	klondikeUndoLimit = 50
+	// klondikeMaxMoveOptions is how many moves fit in a select menu
+	klondikeMaxMoveOptions = 25
+)
+
+// Kinds of piles cards can be moved from and to
+const (
+	klondikeWaste = iota
+	klondikeFoundation
+	klondikeTableau
+)
+
+// klondikePile names a pile of the board
+type klondikePile struct {
+	kind int
+	// index is the column of a tableau pile from 0, or the suit of a foundation. A foundation of -1 is the one
+	// matching the suit of the card moved to it.
+	index int
+}
+
+// String returns the pile in the notation of /move: "w" for the waste, "f" or "fH" for a foundation, or the column from 1
+func (p klondikePile) String() string {
+	switch p.kind {
+	case klondikeWaste:
+		return "w"
+	case klondikeFoundation:
+		if p.index < 0 {
+			return "f"
+		}
+		return "f" + suitLetter(playingcards.Suit(p.index))
+	default:
+		return strconv.Itoa(p.index + 1)
+	}
+}
+
+// describe returns the pile's name in a sentence
+func (p klondikePile) describe() string {
+	switch p.kind {
+	case klondikeWaste:
+		return "the waste"
+	case klondikeFoundation:
+		return "the foundation"
+	default:
+		return fmt.Sprintf("column %d", p.index+1)
+	}
+}
+
+// suitLetter returns the letter of a suit in short card notation, like "H" for Hearts
+func suitLetter(suit playingcards.Suit) string {
+	short := playingcards.NewCard(1, suit).Short()
+	return short[len(short)-1:]
+}
+
+// parseKlondikePile reads a pile written in the notation of /move.
+// The waste can be written "w", a foundation "f" followed by a suit letter, and a tableau column by its number from 1.
+// A plain "f" is the foundation of the card's suit, so it can only be moved to.
+func parseKlondikePile(text string) (klondikePile, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "w", "waste":
+		return klondikePile{kind: klondikeWaste}, nil
+	case "f", "foundation":
+		return klondikePile{kind: klondikeFoundation, index: -1}, nil
+	}
+	if column, err := strconv.Atoi(text); err == nil {
+		if column < 1 || column > klondikeColumns {
+			return klondikePile{}, fmt.Errorf("There is no column %d, the columns go from 1 to %d.", column, klondikeColumns)
+		}
+		return klondikePile{kind: klondikeTableau, index: column - 1}, nil
+	}
+	if strings.HasPrefix(text, "f") {
+		if suit, err := playingcards.ParseSuit(strings.TrimPrefix(text, "f")); err == nil && suit <= playingcards.SPADES {
+			return klondikePile{kind: klondikeFoundation, index: int(suit)}, nil
+		}
+		for suit := playingcards.CLUBS; suit <= playingcards.SPADES; suit++ {
+			if strings.EqualFold(text, "f"+suitLetter(suit)) {
+				return klondikePile{kind: klondikeFoundation, index: int(suit)}, nil
+			}
+		}
+	}
+	return klondikePile{}, fmt.Errorf("Couldn't read the pile %q. Use `w` for the waste, `f` for the foundations, or a column from 1 to %d.", text, klondikeColumns)
+}
+
+// klondikeBoard is the layout of the cards in a game of Klondike
+type klondikeBoard struct {
+	// stock and waste have their top card last
+	stock []playingcards.Card
+	waste []playingcards.Card
+	// foundations are indexed by suit, and build up from the ace
+	foundations [4][]playingcards.Card
+	// Each tableau column has its face down cards, then the face up cards built on them, the last one on top
+	down [klondikeColumns][]playingcards.Card
+	up   [klondikeColumns][]playingcards.Card
+}
+
+func copyCards(cards []playingcards.Card) []playingcards.Card {
+	copied := make([]playingcards.Card, len(cards))
+	copy(copied, cards)
+	return copied
+}
+
+// copy returns a board that shares no cards with this one
+func (b *klondikeBoard) copy() klondikeBoard {
+	copied := klondikeBoard{stock: copyCards(b.stock), waste: copyCards(b.waste)}
+	for i := range b.foundations {
+		copied.foundations[i] = copyCards(b.foundations[i])
+	}
+	for i := range b.down {
+		copied.down[i] = copyCards(b.down[i])
+		copied.up[i] = copyCards(b.up[i])
+	}
+	return copied
+}
+
+// encode writes the whole board, face down cards included, in a compact form for storage
+func (b *klondikeBoard) encode() string {
+	piles := [][]playingcards.Card{b.stock, b.waste}
+	piles = append(piles, b.foundations[:]...)
+	piles = append(piles, b.down[:]...)
+	piles = append(piles, b.up[:]...)
+	encoded := make([]string, len(piles))
+	for n, pile := range piles {
+		codes := make([]string, len(pile))
+		for k, card := range pile {
+			codes[k] = card.Short()
+		}
+		encoded[n] = strings.Join(codes, " ")
+	}
+	return strings.Join(encoded, "|")
+}
+
+// decodeKlondikeBoard reads a board written by encode
+func decodeKlondikeBoard(encoded string) (klondikeBoard, error) {
+	var b klondikeBoard
+	sections := strings.Split(encoded, "|")
+	if len(sections) != 2+4+2*klondikeColumns {
+		return b, errors.New("the board doesn't have the right number of piles")
+	}
+	piles := make([][]playingcards.Card, len(sections))
+	for n, section := range sections {
+		cards, err := playingcards.ParseCards(section)
+		if err != nil {
+			return b, err
+		}
+		piles[n] = cards
+	}
+	b.stock, b.waste = piles[0], piles[1]
+	copy(b.foundations[:], piles[2:6])
+	copy(b.down[:], piles[6:6+klondikeColumns])
+	copy(b.up[:], piles[6+klondikeColumns:])
+	return b, nil
+}
+
+// top returns the top card of the pile, if it has one
+func (b *klondikeBoard) top(p klondikePile) (playingcards.Card, bool) {
+	var cards []playingcards.Card
+	switch p.kind {
+	case klondikeWaste:
+		cards = b.waste
+	case klondikeFoundation:
+		if p.index < 0 {
+			return playingcards.EmptyCard, false
+		}
+		cards = b.foundations[p.index]
+	default:
+		cards = b.up[p.index]
+	}
+	if len(cards) == 0 {
+		return playingcards.EmptyCard, false
+	}
+	return cards[len(cards)-1], true
+}
+
+// canPlace returns whether the card can go on top of the pile, and the pile it lands on,
+// which is the foundation of its suit for a plain foundation
+func (b *klondikeBoard) canPlace(card playingcards.Card, p klondikePile) (klondikePile, bool) {
+	switch p.kind {
+	case klondikeFoundation:
+		if p.index < 0 {
+			p.index = int(card.Suit())
+		}
+		if int(card.Suit()) != p.index {
+			return p, false
+		}
+		return p, card.Value() == len(b.foundations[p.index])+1
+	case klondikeTableau:
+		top, ok := b.top(p)
+		if !ok {
+			// Only a king can fill an empty column
+			return p, card.Value() == 13 && len(b.down[p.index]) == 0
+		}
+		topColor, _ := top.Suit().Color()
+		color, _ := card.Suit().Color()
+		return p, topColor != color && card.Value() == top.Value()-1
+	}
+	return p, false
+}
+
+// movable returns the cards that would be moved from a pile. A count of 0 picks how many cards of a tableau column
+// to move so they fit on the destination, or else moves one card.
+func (b *klondikeBoard) movable(from klondikePile, to klondikePile, count int) ([]playingcards.Card, error) {
+	if from.kind != klondikeTableau {
+		if count > 1 {
+			return nil, errors.New("Only one card at a time can be moved from there.")
+		}
+		top, ok := b.top(from)
+		if !ok {
+			return nil, fmt.Errorf("There are no cards in %s.", from.describe())
+		}
+		return []playingcards.Card{top}, nil
+	}
+	up := b.up[from.index]
+	if len(up) == 0 {
+		return nil, fmt.Errorf("There are no cards in %s.", from.describe())
+	}
+	if count == 0 {
+		count = 1
+		if to.kind == klondikeTableau {
+			for n := len(up); n >= 1; n-- {
+				if _, ok := b.canPlace(up[len(up)-n], to); ok {
+					count = n
+					break
+				}
+			}
+		}
+	}
+	if count > len(up) {
+		return nil, fmt.Errorf("There are only %d face up cards in %s.", len(up), from.describe())
+	}
+	if count > 1 && to.kind == klondikeFoundation {
+		return nil, errors.New("Cards go to the foundations one at a time.")
+	}
+	return up[len(up)-count:], nil
+}
+
+// move moves count cards from one pile to another, and turns up the card they uncover in the tableau.
+// A count of 0 moves as many cards as fit.
+func (b *klondikeBoard) move(from klondikePile, to klondikePile, count int) error {
+	if from.kind == klondikeFoundation && from.index < 0 {
+		return errors.New("Pick which foundation to take a card from, like `fH` for Hearts.")
+	}
+	if to.kind == klondikeWaste {
+		return errors.New("Cards can't be moved to the waste.")
+	}
+	if from == to {
+		return errors.New("The cards are already there.")
+	}
+	cards, err := b.movable(from, to, count)
+	if err != nil {
+		return err
+	}
+	to, ok := b.canPlace(cards[0], to)
+	if !ok {
+		if to.kind == klondikeFoundation {
+			return fmt.Errorf("The %s can't go on the foundation yet.", cards[0].String())
+		}
+		if top, ok := b.top(to); ok {
+			return fmt.Errorf("The %s can't go on the %s. Build down in alternating colors.", cards[0].String(), top.String())
+		}
+		return fmt.Errorf("Only a king can go in an empty column, not the %s.", cards[0].String())
+	}
+
+	moved := copyCards(cards)
+	switch from.kind {
+	case klondikeWaste:
+		b.waste = b.waste[:len(b.waste)-1]
+	case klondikeFoundation:
+		b.foundations[from.index] = b.foundations[from.index][:len(b.foundations[from.index])-1]
+	default:
+		b.up[from.index] = b.up[from.index][:len(b.up[from.index])-len(moved)]
+		if down := b.down[from.index]; len(b.up[from.index]) == 0 && len(down) > 0 {
+			b.up[from.index] = []playingcards.Card{down[len(down)-1]}
+			b.down[from.index] = down[:len(down)-1]
+		}
+	}
+	if to.kind == klondikeFoundation {
+		b.foundations[to.index] = append(b.foundations[to.index], moved...)
+	} else {
+		b.up[to.index] = append(b.up[to.index], moved...)
+	}
+	return nil
+}
+
+// draw turns up to drawCount cards from the stock onto the waste, or turns the waste over into a new stock
+func (b *klondikeBoard) draw(drawCount int) error {
+	if len(b.stock) == 0 {
+		if len(b.waste) == 0 {
+			return errors.New("There are no cards left in the stock or the waste.")
+		}
+		for n := len(b.waste) - 1; n >= 0; n-- {
+			b.stock = append(b.stock, b.waste[n])
+		}
+		b.waste = nil
+		return nil
+	}
+	for n := 0; n < drawCount && len(b.stock) > 0; n++ {
+		b.waste = append(b.waste, b.stock[len(b.stock)-1])
+		b.stock = b.stock[:len(b.stock)-1]
+	}
+	return nil
+}
+
+// won returns whether every card made it to the foundations
+func (b *klondikeBoard) won() bool {
+	for _, foundation := range b.foundations {
+		if len(foundation) != 13 {
+			return false
+		}
+	}
+	return true
+}
+
+// canAutoFinish returns whether the game is sure to be won: every card left is face up in the tableau,
+// so the lowest of them is always free to go to its foundation
+func (b *klondikeBoard) canAutoFinish() bool {
+	if len(b.stock) > 0 || len(b.waste) > 0 {
+		return false
+	}
+	for _, down := range b.down {
+		if len(down) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// autoFinish moves the cards of the tableau to the foundations until none can go, and returns how many moved
+func (b *klondikeBoard) autoFinish() int {
+	moved := 0
+	for progress := true; progress; {
+		progress = false
+		for column := 0; column < klondikeColumns; column++ {
+			from := klondikePile{kind: klondikeTableau, index: column}
+			if b.move(from, klondikePile{kind: klondikeFoundation, index: -1}, 1) == nil {
+				moved++
+				progress = true
+			}
+		}
+	}
+	return moved
+}
+
+// klondikeMove is a move of cards between two piles
+type klondikeMove struct {
+	from  klondikePile
+	to    klondikePile
+	count int
+}
+
+// value writes the move as "from>to>count", for select menus
+func (m klondikeMove) value() string {
+	return fmt.Sprintf("%s>%s>%d", m.from, m.to, m.count)
+}
+
+// parseKlondikeMove reads a move written by value
+func parseKlondikeMove(value string) (klondikeMove, error) {
+	parts := strings.Split(value, ">")
+	if len(parts) != 3 {
+		return klondikeMove{}, errors.New("Unknown move.")
+	}
+	from, err := parseKlondikePile(parts[0])
+	if err != nil {
+		return klondikeMove{}, err
+	}
+	to, err := parseKlondikePile(parts[1])
+	if err != nil {
+		return klondikeMove{}, err
+	}
+	count, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return klondikeMove{}, errors.New("Unknown move.")
+	}
+	return klondikeMove{from: from, to: to, count: count}, nil
+}
+
+// legalMoves lists the moves that can be made, moves to the foundations first
+func (b *klondikeBoard) legalMoves() []klondikeMove {
+	moves := []klondikeMove{}
+	foundation := klondikePile{kind: klondikeFoundation, index: -1}
+	waste := klondikePile{kind: klondikeWaste}
+	if top, ok := b.top(waste); ok {
+		if _, ok := b.canPlace(top, foundation); ok {
+			moves = append(moves, klondikeMove{from: waste, to: foundation, count: 1})
+		}
+	}
+	for column := 0; column < klondikeColumns; column++ {
+		from := klondikePile{kind: klondikeTableau, index: column}
+		if top, ok := b.top(from); ok {
+			if _, ok := b.canPlace(top, foundation); ok {
+				moves = append(moves, klondikeMove{from: from, to: foundation, count: 1})
+			}
+		}
+	}
+	if top, ok := b.top(waste); ok {
+		for column := 0; column < klondikeColumns; column++ {
+			to := klondikePile{kind: klondikeTableau, index: column}
+			if _, ok := b.canPlace(top, to); ok {
+				moves = append(moves, klondikeMove{from: waste, to: to, count: 1})
+			}
+		}
+	}
+	for column := 0; column < klondikeColumns; column++ {
+		from := klondikePile{kind: klondikeTableau, index: column}
+		up := b.up[column]
+		for count := len(up); count >= 1; count-- {
+			// Moving a whole column that has nothing under it to an empty column changes nothing
+			if count == len(up) && len(b.down[column]) == 0 && up[0].Value() == 13 {
+				continue
+			}
+			for target := 0; target < klondikeColumns; target++ {
+				to := klondikePile{kind: klondikeTableau, index: target}
+				if target == column {
+					continue
+				}
+				if _, ok := b.canPlace(up[len(up)-count], to); ok {
+					moves = append(moves, klondikeMove{from: from, to: to, count: count})
+				}
+			}
+		}
+	}
+	for suit := range b.foundations {
+		from := klondikePile{kind: klondikeFoundation, index: suit}
+		if top, ok := b.top(from); ok {
+			for column := 0; column < klondikeColumns; column++ {
+				to := klondikePile{kind: klondikeTableau, index: column}
+				if _, ok := b.canPlace(top, to); ok {
+					moves = append(moves, klondikeMove{from: from, to: to, count: 1})
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// KlondikeGame is a game of Klondike Solitaire played by one user. It is kept in the server state,
+// and only used while the server state is locked.
+type KlondikeGame struct {
+	drawCount int
+	board     klondikeBoard
+	// history holds the boards before each move, the latest last, so moves can be undone
+	history []klondikeBoard
+	moves   int
+}
+
+// NewKlondikeGame shuffles a deck and deals a game of Klondike, turning drawCount cards from the stock at a time
+func NewKlondikeGame(drawCount int) *KlondikeGame {
+	game := &KlondikeGame{drawCount: drawCount}
+	deck := newStandardDeck()
+	deck.Shuffle()
+	for row := 0; row < klondikeColumns; row++ {
+		for column := row; column < klondikeColumns; column++ {
+			if column == row {
+				game.board.up[column] = append(game.board.up[column], deck.DrawCard())
+			} else {
+				game.board.down[column] = append(game.board.down[column], deck.DrawCard())
+			}
+		}
+	}
+	game.board.stock, _ = deck.DrawCards(deck.Size())
+	return game
+}
+
+// klondikeJSON is how a game of Klondike is stored
+type klondikeJSON struct {
+	DrawCount int      `json:"drawCount"`
+	Board     string   `json:"board"`
+	History   []string `json:"history,omitempty"`
+	Moves     int      `json:"moves"`
+}
+
+// MarshalJSON stores the game, with the boards written compactly
+func (g *KlondikeGame) MarshalJSON() ([]byte, error) {
+	stored := klondikeJSON{DrawCount: g.drawCount, Board: g.board.encode(), Moves: g.moves}
+	for _, board := range g.history {
+		stored.History = append(stored.History, board.encode())
+	}
+	return json.Marshal(stored)
+}
+
+// UnmarshalJSON restores a game stored by MarshalJSON
+func (g *KlondikeGame) UnmarshalJSON(data []byte) error {
+	var stored klondikeJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	board, err := decodeKlondikeBoard(stored.Board)
+	if err != nil {
+		return err
+	}
+	history := []klondikeBoard{}
+	for _, encoded := range stored.History {
+		previous, err := decodeKlondikeBoard(encoded)
+		if err != nil {
+			return err
+		}
+		history = append(history, previous)
+	}
+	g.drawCount, g.board, g.history, g.moves = stored.DrawCount, board, history, stored.Moves
+	return nil
+}
+
+// do applies a change to the board, remembering the board before it so it can be undone
+func (g *KlondikeGame) do(change func(b *klondikeBoard) error) error {
+	before := g.board.copy()
+	if err := change(&g.board); err != nil {
+		g.board = before
+		return err
+	}
+	g.history = append(g.history, before)
+	if len(g.history) > klondikeUndoLimit {
+		g.history = g.history[len(g.history)-klondikeUndoLimit:]
+	}
+	g.moves++
+	return nil
+}
+
+// Draw turns cards from the stock, or turns the waste over once the stock is empty
+func (g *KlondikeGame) Draw() error {
+	return g.do(func(b *klondikeBoard) error {
+		return b.draw(g.drawCount)
+	})
+}
+
+// Move moves count cards between two piles, as many as fit if count is 0.
+// Once the game can't be lost anymore, the rest of the cards go to the foundations, and it returns true.
+func (g *KlondikeGame) Move(from klondikePile, to klondikePile, count int) (bool, error) {
+	err := g.do(func(b *klondikeBoard) error {
+		return b.move(from, to, count)
+	})
+	if err != nil {
+		return false, err
+	}
+	if !g.board.won() && g.board.canAutoFinish() {
+		g.board.autoFinish()
+		return true, nil
+	}
+	return false, nil
+}
+
+// Undo takes back the last move, which no longer counts as a move
+func (g *KlondikeGame) Undo() error {
+	if len(g.history) == 0 {
+		return errors.New("There are no moves to undo.")
+	}
+	g.board = g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	g.moves--
+	return nil
+}
+
+// Won returns whether every card made it to the foundations
+func (g *KlondikeGame) Won() bool {
+	return g.board.won()
+}
+
+// Discord side of the game
+
+// klondikeCommand handles the /klondike slash command by showing the user's game, dealing a new one if needed
+func klondikeCommand(s Session, i *discordgo.InteractionCreate) {
+	drawCount, newGame := 1, false
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "draw":
+			drawCount = int(opt.IntValue())
+		case "new":
+			newGame = opt.BoolValue()
+		}
+	}
+
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	userID := interactionUserID(i)
+	game := state.solitaire[userID]
+	status := "Resuming your game. Use the `new` option to start over."
+	if game == nil || newGame {
+		game = NewKlondikeGame(drawCount)
+		state.solitaire[userID] = game
+		state.save()
+		status = "Dealt a new game. Good luck!"
+	}
+	data := klondikeMessage(state, userID, status)
+	state.mu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
+
+// klondikeComponent handles the buttons and menu of a game of Klondike. Their custom IDs end with the player's ID,
+// so only they can use them.
+func klondikeComponent(s Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	parts := strings.Split(data.CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	userID := interactionUserID(i)
+	if parts[2] != userID {
+		respondEphemeral(s, i, "This isn't your game. Start your own with /klondike.")
+		return
+	}
+	value := ""
+	if len(data.Values) > 0 {
+		value = data.Values[0]
+	}
+
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	response, err := klondikeAction(state, userID, parts[1], value)
+	state.mu.Unlock()
+
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: response,
+	})
+}
+
+// klondikeAction applies a button or menu choice to the user's game, and returns the updated game message.
+// The state must be locked.
+func klondikeAction(state *ServerState, userID string, action string, value string) (*discordgo.InteractionResponseData, error) {
+	game := state.solitaire[userID]
+	if game == nil {
+		return nil, errors.New("You don't have a game of Klondike in progress. Start one with /klondike.")
+	}
+	status := ""
+	switch action {
+	case "draw":
+		if err := game.Draw(); err != nil {
+			return nil, err
+		}
+	case "undo":
+		if err := game.Undo(); err != nil {
+			return nil, err
+		}
+		status = "Took back the last move."
+	case "move":
+		move, err := parseKlondikeMove(value)
+		if err != nil {
+			return nil, err
+		}
+		finished, err := game.Move(move.from, move.to, move.count)
+		if err != nil {
+			return nil, err
+		}
+		if finished {
+			status = "Every card left is face up, so the rest went to the foundations."
+		}
+	case "quit":
+		delete(state.solitaire, userID)
+		state.save()
+		return &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("%s gave up their game of Klondike after %d moves.", mention(userID), game.moves),
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		}, nil
+	default:
+		return nil, errors.New("Unknown action.")
+	}
+	state.save()
+	return klondikeMessage(state, userID, status), nil
+}
+
+// klondikeMoveCommand handles the /move slash command by moving cards in the user's game
+func klondikeMoveCommand(s Session, i *discordgo.InteractionCreate) {
+	from, to, count := "", "", 0
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "from":
+			from = opt.StringValue()
+		case "to":
+			to = opt.StringValue()
+		case "count":
+			count = int(opt.IntValue())
+		}
+	}
+
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	data := klondikeMoveResponse(state, interactionUserID(i), from, to, count)
+	state.mu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
+
+// klondikeMoveResponse applies a /move to the user's game, and returns the updated game message, or an error only they see.
+// The state must be locked.
+func klondikeMoveResponse(state *ServerState, userID string, fromText string, toText string, count int) *discordgo.InteractionResponseData {
+	errorResponse := func(err error) *discordgo.InteractionResponseData {
+		return &discordgo.InteractionResponseData{
+			Content: err.Error(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}
+	}
+	game := state.solitaire[userID]
+	if game == nil {
+		return errorResponse(errors.New("You don't have a game of Klondike in progress. Start one with /klondike."))
+	}
+	from, err := parseKlondikePile(fromText)
+	if err != nil {
+		return errorResponse(err)
+	}
+	to, err := parseKlondikePile(toText)
+	if err != nil {
+		return errorResponse(err)
+	}
+	finished, err := game.Move(from, to, count)
+	if err != nil {
+		return errorResponse(err)
+	}
+	status := ""
+	if finished {
+		status = "Every card left is face up, so the rest went to the foundations."
+	}
+	state.save()
+	return klondikeMessage(state, userID, status)
+}
+
+// klondikeMessage returns the message showing the user's game, with the buttons and the menu of moves to play it.
+// A game that was won is removed. The state must be locked.
+func klondikeMessage(state *ServerState, userID string, status string) *discordgo.InteractionResponseData {
+	game := state.solitaire[userID]
+	board := &game.board
+	mode := "Draw 1"
+	if game.drawCount > 1 {
+		mode = fmt.Sprintf("Draw %d", game.drawCount)
+	}
+	embed := &discordgo.MessageEmbed{
+		Color: 0x3dbb6b,
+		Title: fmt.Sprintf("Klondike (%s)", mode),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Stock: %d • Waste: %d • Moves: %d • Move cards with the menu or /move, like /move from:w to:f",
+				len(board.stock), len(board.waste), game.moves),
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: GetKlondikeURL(board, game.drawCount, state.cardsStyle),
+		},
+	}
+	description := []string{fmt.Sprintf("%s's game.", mention(userID))}
+	if status != "" {
+		description = append(description, status)
+	}
+
+	if game.Won() {
+		delete(state.solitaire, userID)
+		state.save()
+		description = append(description, fmt.Sprintf("**You won in %d moves!** 🎉", game.moves))
+		embed.Description = strings.Join(description, "\n")
+		return &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		}
+	}
+
+	moves := board.legalMoves()
+	if len(moves) == 0 && len(board.stock) == 0 && len(board.waste) == 0 {
+		description = append(description, "There are no moves left. Undo some moves, or give up and start a new game.")
+	}
+	embed.Description = strings.Join(description, "\n")
+
+	drawLabel := "Draw"
+	if len(board.stock) == 0 {
+		drawLabel = "Turn over the waste"
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: drawLabel, Style: discordgo.PrimaryButton, CustomID: "klondike:draw:" + userID,
+					Disabled: len(board.stock) == 0 && len(board.waste) == 0},
+				discordgo.Button{Label: "Undo", Style: discordgo.SecondaryButton, CustomID: "klondike:undo:" + userID,
+					Disabled: len(game.history) == 0},
+				discordgo.Button{Label: "Give up", Style: discordgo.DangerButton, CustomID: "klondike:quit:" + userID},
+			},
+		},
+	}
+	if len(moves) > 0 {
+		options := []discordgo.SelectMenuOption{}
+		for _, move := range moves {
+			if len(options) == klondikeMaxMoveOptions {
+				break
+			}
+			cards, _ := board.movable(move.from, move.to, move.count)
+			label := fmt.Sprintf("%s: %s → %s", cards[0].String(), move.from.describe(), move.to.describe())
+			if len(cards) > 1 {
+				label = fmt.Sprintf("%s and %d more: %s → %s", cards[0].String(), len(cards)-1, move.from.describe(), move.to.describe())
+			}
+			options = append(options, discordgo.SelectMenuOption{Label: label, Value: move.value()})
+		}
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: "klondike:move:" + userID, Placeholder: "Move cards", Options: options},
+			},
+		})
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}
+}
+
+// Board images
+
+// imageCode writes the visible part of the board for image URLs: the number of cards in the stock,
+// the waste cards showing, the top card of each foundation, and for each column the number of face down cards
+// followed by the face up cards. Sections are separated by "_", cards by "-", and an empty pile is "x".
+func (b *klondikeBoard) imageCode(drawCount int) string {
+	codes := func(cards []playingcards.Card) string {
+		if len(cards) == 0 {
+			return "x"
+		}
+		return cardCodes(cards)
+	}
+	sections := []string{strconv.Itoa(len(b.stock)), codes(b.waste[len(b.waste)-minInt(drawCount, len(b.waste)):])}
+	for _, foundation := range b.foundations {
+		if len(foundation) == 0 {
+			sections = append(sections, "x")
+		} else {
+			sections = append(sections, foundation[len(foundation)-1].Short())
+		}
+	}
+	for column := 0; column < klondikeColumns; column++ {
+		section := strconv.Itoa(len(b.down[column]))
+		if len(b.up[column]) > 0 {
+			section += "-" + cardCodes(b.up[column])
+		}
+		sections = append(sections, section)
+	}
+	return strings.Join(sections, "_")
+}
+
+// GetKlondikeURL returns the full url to the image of a Klondike board in the given style
+func GetKlondikeURL(b *klondikeBoard, drawCount int, style int) string {
+	return signedImageURL(fmt.Sprintf("/klondike/%s/%s.png", styleNames[style], b.imageCode(drawCount)))
+}
+
+// klondikeView is the visible part of a board, read back from an image code
+type klondikeView struct {
+	stock       int
+	waste       []playingcards.Card
+	foundations [4][]playingcards.Card
+	down        [klondikeColumns]int
+	up          [klondikeColumns][]playingcards.Card
+}
+
+// parseKlondikeImageCode reads the board written by klondikeBoard.imageCode
+func parseKlondikeImageCode(code string) (*klondikeView, error) {
+	sections := strings.Split(code, "_")
+	if len(sections) != 2+4+klondikeColumns {
+		return nil, errors.New("the board doesn't have the right number of piles")
+	}
+	parseCodes := func(text string) ([]playingcards.Card, error) {
+		if text == "x" || text == "" {
+			return nil, nil
+		}
+		cards := []playingcards.Card{}
+		for _, cardCode := range strings.Split(text, "-") {
+			card, err := playingcards.ParseCard(cardCode)
+			if err != nil {
+				return nil, err
+			}
+			cards = append(cards, card)
+		}
+		return cards, nil
+	}
+	view := &klondikeView{}
+	var err error
+	if view.stock, err = strconv.Atoi(sections[0]); err != nil || view.stock < 0 || view.stock > 52 {
+		return nil, errors.New("invalid stock")
+	}
+	if view.waste, err = parseCodes(sections[1]); err != nil {
+		return nil, err
+	}
+	for n := 0; n < 4; n++ {
+		if view.foundations[n], err = parseCodes(sections[2+n]); err != nil {
+			return nil, err
+		}
+	}
+	for column := 0; column < klondikeColumns; column++ {
+		parts := strings.SplitN(sections[6+column], "-", 2)
+		if view.down[column], err = strconv.Atoi(parts[0]); err != nil || view.down[column] < 0 || view.down[column] > 52 {
+			return nil, errors.New("invalid column")
+		}
+		if len(parts) == 2 {
+			if view.up[column], err = parseCodes(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+		if len(view.up[column]) > 52 {
+			return nil, errors.New("invalid column")
+		}
+	}
+	return view, nil
+}
+
+var (
+	klondikeFelt    = color.RGBA{0x2e, 0x7d, 0x32, 0xff}
+	klondikeOutline = color.RGBA{0x66, 0xbb, 0x6a, 0xff}
+)
+
+// RenderKlondike returns the PNG image of a Klondike board written by klondikeBoard.imageCode
+func (r *CardRenderer) RenderKlondike(code string, style int) ([]byte, error) {
+	view, err := parseKlondikeImageCode(code)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("klondike/%d/%s", style, code)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data, ok := r.rendered[key]; ok {
+		return data, nil
+	}
+	back, err := r.loadImage(GetCardBackPath(style))
+	if err != nil {
+		return nil, err
+	}
+	cardWidth, cardHeight := back.Bounds().Dx(), back.Bounds().Dy()
+	downStep, upStep := cardHeight/12, cardHeight/4
+	wasteStep := cardWidth / 4
+	tableauY := 3*cardSpacing + cardHeight
+
+	height := tableauY + cardHeight + cardSpacing
+	for column := 0; column < klondikeColumns; column++ {
+		columnHeight := tableauY + view.down[column]*downStep + maxInt(len(view.up[column])-1, 0)*upStep + cardHeight + cardSpacing
+		height = maxInt(height, columnHeight)
+	}
+	width := klondikeColumns*(cardWidth+cardSpacing) + cardSpacing
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{klondikeFelt}, image.Point{}, draw.Src)
+
+	columnX := func(column int) int {
+		return cardSpacing + column*(cardWidth+cardSpacing)
+	}
+	drawImage := func(img *image.RGBA, x int, y int) {
+		draw.Draw(canvas, img.Bounds().Add(image.Pt(x, y)), img, image.Point{}, draw.Over)
+	}
+	drawCard := func(card playingcards.Card, x int, y int) error {
+		img, err := r.cardImage(card, style)
+		if err != nil {
+			return err
+		}
+		drawImage(img, x, y)
+		return nil
+	}
+	drawSlot := func(x int, y int) {
+		slot := image.Rect(x, y, x+cardWidth, y+cardHeight)
+		for _, edge := range []image.Rectangle{
+			{slot.Min, image.Pt(slot.Max.X, slot.Min.Y+2)},
+			{image.Pt(slot.Min.X, slot.Max.Y-2), slot.Max},
+			{slot.Min, image.Pt(slot.Min.X+2, slot.Max.Y)},
+			{image.Pt(slot.Max.X-2, slot.Min.Y), slot.Max},
+		} {
+			draw.Draw(canvas, edge, &image.Uniform{klondikeOutline}, image.Point{}, draw.Src)
+		}
+	}
+
+	// The stock and waste on the left of the top row, the foundations on the right
+	if view.stock > 0 {
+		drawImage(back, columnX(0), cardSpacing)
+	} else {
+		drawSlot(columnX(0), cardSpacing)
+	}
+	if len(view.waste) == 0 {
+		drawSlot(columnX(1), cardSpacing)
+	}
+	for n, card := range view.waste {
+		if err := drawCard(card, columnX(1)+n*wasteStep, cardSpacing); err != nil {
+			return nil, err
+		}
+	}
+	for n, foundation := range view.foundations {
+		if len(foundation) == 0 {
+			drawSlot(columnX(3+n), cardSpacing)
+		} else if err := drawCard(foundation[0], columnX(3+n), cardSpacing); err != nil {
+			return nil, err
+		}
+	}
+	for column := 0; column < klondikeColumns; column++ {
+		x, y := columnX(column), tableauY
+		if view.down[column] == 0 && len(view.up[column]) == 0 {
+			drawSlot(x, y)
+			continue
+		}
+		for n := 0; n < view.down[column]; n++ {
+			drawImage(back, x, y)
+			y += downStep
+		}
+		for _, card := range view.up[column] {
+			if err := drawCard(card, x, y); err != nil {
+				return nil, err
+			}
+			y += upStep
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	r.cache(key, data)
+	return data, nil
+}
+
+// maxInt returns the larger of two ints
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// klondikeImageHandler serves the images made by GetKlondikeURL, at /klondike/<style>/<board>.png
+func klondikeImageHandler(w http.ResponseWriter, r *http.Request) {
+	if !validImageRequest(r) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/klondike/"), "/")
+	if len(parts) != 2 || !strings.HasSuffix(parts[1], ".png") {
+		http.NotFound(w, r)
+		return
+	}
+	style := -1
+	for s, name := range styleNames {
+		if name == parts[0] {
+			style = s
+		}
+	}
+	if style < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := renderer.RenderKlondike(strings.TrimSuffix(parts[1], ".png"), style)
+	if err != nil {
+		log.Println("Error rendering Klondike image,", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(data)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+// TestKlondikeUndo checks that undoing a draw puts the board back and takes the draw off the move count
+func TestKlondikeUndo(t *testing.T) {
+	game := NewKlondikeGame(1)
+	before := game.board.encode()
+	if err := game.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if game.moves != 2 {
+		t.Fatalf("%d moves after drawing twice, want 2", game.moves)
+	}
+	for n := 0; n < 2; n++ {
+		if err := game.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if game.moves != 0 {
+		t.Errorf("%d moves after undoing both draws, want 0", game.moves)
+	}
+	if game.board.encode() != before {
+		t.Error("undoing both draws didn't restore the dealt board")
+	}
+	if err := game.Undo(); err == nil {
+		t.Error("undo with nothing left to undo didn't fail")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -22,14 +23,24 @@ var (
 	GuildID        = flag.String("guild", "", "Test guild ID. If not passed - bot registers commands globally")
 	AppID          string
 	RemoveCommands = flag.Bool("rmcmd", true, "Remove all commands after shutdowning or not")
+	DataDir        string
+	ShuffleSeed    = flag.Int64("seed", 0, "Seed for shuffling the decks, so games can be replayed. If not passed - decks are shuffled randomly")
+	CryptoShuffle  = flag.Bool("crypto-shuffle", false, "Shuffle the decks using crypto/rand")
 )
 
 func init() {
 	flag.StringVar(&Token, "t", "", "Bot Token")
 	flag.StringVar(&AppID, "app", "", "Application ID")
+	flag.StringVar(&DataDir, "data", "", "Directory where server states are saved")
+}
+
+// parseFlags reads the command line, falling back to environment variables for the settings that weren't passed.
+// It runs from main rather than init so that tests can use their own flags.
+func parseFlags() {
 	flag.Parse()
 	tokenFound := false
 	appIDFound := false
+	dataDirFound := false
 	flag.Visit(func(f *flag.Flag) {
 		if f.Name == "t" {
 			if len(f.Value.String()) > 0 {
@@ -41,6 +52,9 @@ func init() {
 				appIDFound = true
 			}
 		}
+		if f.Name == "data" {
+			dataDirFound = true
+		}
 	})
 	if !tokenFound {
 		// Bot token is read as an environment variable if no command line argument was found
@@ -49,48 +63,61 @@ func init() {
 	if !appIDFound {
 		AppID = os.Getenv("APPLICATION_ID")
 	}
+	if !dataDirFound {
+		DataDir = os.Getenv("DATA_DIR")
+		if len(DataDir) == 0 {
+			DataDir = "data"
+		}
+	}
 }
 
 // Constants for the games supported by the bot
 const (
 	NoGame int = iota
 	HighOrLow
+	TexasHoldem
+	Blackjack
+	War
+	GoFish
+	CrazyEights
+	Klondike
+	Hearts
 )
 
-// Constants that represent a player's decision in a High or Low game
-const (
-	NoGuess int = iota
-	High
-	Low
-)
-
-// PlayerState represents a player's state during a game of High or Low
-type PlayerState struct {
-	choice int
-	active bool
-}
-
-// Active returns whether a player is still in the currently running game or not
-func (p PlayerState) Active() bool {
-	return p.active
+// GameState represents the current game running in a channel
+type GameState struct {
+	gameType int
+	current  Game
 }
 
-// GameState represents the current game running in a Discord server
-type GameState struct {
-	gameType      int
-	channelID     string
-	lastMessageID string
-	preStartPhase bool
+// ChannelState holds the deck and the game of a single channel or thread in a Discord server,
+// so each channel can run its own game. It must only be used while holding its server's mu.
+type ChannelState struct {
+	id   string
+	deck playingcards.Deck
+	// zones holds the cards that left the deck outside of games, such as each player's drawn cards and the discard pile
+	zones playingcards.Zones
+	game  GameState
+	// lastShuffle is the proof of the last provably fair game that finished in the channel
+	lastShuffle *ShuffleProof
 }
 
-// ServerState holds data on the current state of a Discord server
+// ServerState holds data on the current state of a Discord server.
+// Every field except id must only be used while holding mu.
 type ServerState struct {
+	mu            sync.Mutex
 	id            string
-	deck          playingcards.Deck
-	game          GameState
-	players       map[string]*PlayerState
+	channels      map[string]*ChannelState
 	cardsStyle    int
 	includeJokers bool
+	numDecks      int
+	// solitaire holds the game of Klondike of each user who has one in progress
+	solitaire map[string]*KlondikeGame
+	// defaultDeck is the deck the server had before decks were kept per channel, or nil.
+	// The first channel to use a deck gets it instead of a new one.
+	defaultDeck *playingcards.Deck
+	// unsaved is set when the stored state couldn't be loaded or set aside, so saving would overwrite it
+	unsaved bool
 }
 
 // Constants that represent what card images to use
@@ -99,24 +126,87 @@ const (
 	KenneyPixel
 )
 
+// Limits and defaults for multi-deck shoes
+const (
+	MaxDecks           = 8
+	DefaultPenetration = 0.75
+)
+
+// MaxDrawCount is the most cards that can be drawn with a single /draw
+const MaxDrawCount = 52
+
 var prefix string = "$pcb "
 
-var serverStates = make(map[string]*ServerState)
+var (
+	serverStates   = make(map[string]*ServerState)
+	serverStatesMu sync.Mutex
+)
 
 // NewServerState creates a new state struct for the given Discord server
 func NewServerState(guildID string) *ServerState {
-	ss := ServerState{id: guildID, players: make(map[string]*PlayerState), cardsStyle: KenneyLarge, includeJokers: false}
+	ss := ServerState{id: guildID, channels: make(map[string]*ChannelState), cardsStyle: KenneyLarge, includeJokers: false, numDecks: 1,
+		solitaire: make(map[string]*KlondikeGame)}
 	return &ss
 }
 
-// GameType returns the type of game currently running in the given Discord server
-func (s ServerState) GameType() int {
-	return s.game.gameType
+// Channel returns the state of the given channel in the server, creating it first if needed,
+// with the server's default deck if it has one or else a new deck. The state must be locked.
+func (s *ServerState) Channel(channelID string) *ChannelState {
+	channel, exists := s.channels[channelID]
+	if !exists {
+		channel = &ChannelState{id: channelID, deck: s.NewDeck()}
+		if s.defaultDeck != nil {
+			channel.deck = *s.defaultDeck
+			s.defaultDeck = nil
+		}
+		s.channels[channelID] = channel
+	}
+	return channel
+}
+
+// resetIdleDecks gives a new deck to every channel without a running game, after the deck settings changed.
+// Channels with a running game get theirs when the game ends. The state must be locked.
+func (s *ServerState) resetIdleDecks() {
+	s.defaultDeck = nil
+	for _, channel := range s.channels {
+		if channel.GameType() == NoGame {
+			channel.setDeck(s.NewDeck())
+		}
+	}
+}
+
+// setDeck gives the channel a new deck, and empties its zones since their cards are part of the new deck.
+// The state must be locked.
+func (c *ChannelState) setDeck(deck playingcards.Deck) {
+	c.deck = deck
+	c.zones = playingcards.Zones{}
+}
+
+// newStandardDeck returns an ordered deck of 52 cards without Jokers,
+// for games whose rules need exactly those cards whatever the server's deck settings are
+func newStandardDeck() playingcards.Deck {
+	return playingcards.NewDeck(false)
 }
 
-// Players returns a list of active (alive) and inactive(dead) players for the current game session in a Discord server
-func (s *ServerState) Players() map[string]*PlayerState {
-	return s.players
+// setStandardDeck gives the channel a new standard deck for a game to deal from, and returns it.
+// The state must be locked.
+func (c *ChannelState) setStandardDeck() *playingcards.Deck {
+	c.setDeck(newStandardDeck())
+	return &c.deck
+}
+
+// GameType returns the type of game currently running in the channel
+func (c *ChannelState) GameType() int {
+	return c.game.gameType
+}
+
+// NewDeck creates a brand new, ordered deck using the server's deck settings
+func (s *ServerState) NewDeck() playingcards.Deck {
+	deck := playingcards.NewShoe(s.numDecks, s.includeJokers)
+	if s.numDecks > 1 {
+		deck.SetPenetration(DefaultPenetration)
+	}
+	return deck
 }
 
 func startServer(server *http.ServeMux) {
@@ -127,6 +217,8 @@ func startServer(server *http.ServeMux) {
 // Slash commands setup
 var (
 	integerOptionMinValue          = 1.0
+	maxDecksOptionValue            = float64(MaxDecks)
+	bigBlindOptionMinValue         = 2.0
 	dmPermission                   = false
 	defaultMemberPermissions int64 = discordgo.PermissionManageServer
 
@@ -145,11 +237,108 @@ var (
 		},
 		{
 			Name:        "quit-game",
-			Description: "Stop any currently running game.",
+			Description: "Stop the game running in this channel.",
 		},
 		{
 			Name:        "draw",
-			Description: "Draw a card from the deck.", // TODO: integer option to draw multiple cards
+			Description: "Draw cards from the deck.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many cards to draw (default 1, up to %d)", MaxDrawCount),
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    MaxDrawCount,
+				},
+			},
+		},
+		{
+			Name:        "deal",
+			Description: "Deal cards from the deck to the players' hands.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "players",
+					Description: "The players to deal to, like \"@alice @bob\"",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "How many cards to deal to each player",
+					Required:    true,
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    MaxDrawCount,
+				},
+			},
+		},
+		{
+			Name:        "hand",
+			Description: "Privately see the cards in your hand.",
+		},
+		{
+			Name:        "play",
+			Description: "Show a card from your hand to the channel.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "card",
+					Description: "The card to play, like \"AS\", \"10H\" or \"Queen of Hearts\"",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "discard",
+			Description: "Put cards you drew onto the discard pile, or shuffle the discard pile back into the deck.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "cards",
+					Description: "The cards to discard, like \"AS 10H QD\" (default: all the cards you drew)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "reshuffle",
+					Description: "Shuffle the discard pile back into the deck",
+				},
+			},
+		},
+		{
+			Name:        "peek",
+			Description: "Look at the top cards of the deck without drawing them.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: fmt.Sprintf("How many cards to look at (default 1, up to %d)", MaxPeekCount),
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    MaxPeekCount,
+				},
+			},
+		},
+		{
+			Name:        "cut",
+			Description: "Cut the deck, moving cards from the top to the bottom.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "position",
+					Description: "How many cards to move from the top to the bottom (default: around the middle)",
+					MinValue:    &integerOptionMinValue,
+				},
+			},
+		},
+		{
+			Name:        "deck-status",
+			Description: "Show what is left in the deck, what was drawn from it, and the deck settings.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "full-listing",
+					Description: "Privately list the remaining cards in order (moderators only)",
+				},
+			},
 		},
 		{
 			Name:        "set-style",
@@ -163,6 +352,20 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "set-decks",
+			Description: "Set how many decks of cards are shuffled together into the shoe.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "The number of decks (1-8)",
+					Required:    true,
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    maxDecksOptionValue,
+				},
+			},
+		},
 		{
 			Name:        "include-jokers",
 			Description: "Set whether the Joker cards should be in the deck or not.",
@@ -175,10 +378,27 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "verify",
+			Description: "Check the shuffle of the last provably fair game, or of the given seeds.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "server-seed",
+					Description: "The server seed revealed at the end of a game",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "client-seed",
+					Description: "The client seed of the game",
+				},
+			},
+		},
 	}
 
-	commandHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"info": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	commandHandlers = map[string]func(s Session, i *discordgo.InteractionCreate){
+		"verify": verifyCommand,
+		"info": func(s Session, i *discordgo.InteractionCreate) {
 			message := &discordgo.MessageEmbed{
 				Color:       0x607d8b,
 				Title:       "Playing Cards Bot Info",
@@ -191,15 +411,19 @@ var (
 				},
 			})
 		},
-		"shuffle": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"shuffle": func(s Session, i *discordgo.InteractionCreate) {
 			msg := ""
 			state := GetServerState(i.GuildID)
-			if state.GameType() != NoGame {
+			state.mu.Lock()
+			channel := state.Channel(i.ChannelID)
+			if channel.GameType() != NoGame {
 				msg = gameInProgressWarning()
 			} else {
-				state.deck.Shuffle()
+				channel.deck.Shuffle()
+				state.save()
 				msg = "Cards shuffled!"
 			}
+			state.mu.Unlock()
 
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -208,15 +432,23 @@ var (
 				},
 			})
 		},
-		"reset-cards": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"reset-cards": func(s Session, i *discordgo.InteractionCreate) {
 			msg := ""
 			state := GetServerState(i.GuildID)
-			if state.GameType() != NoGame {
+			state.mu.Lock()
+			channel := state.Channel(i.ChannelID)
+			if channel.GameType() != NoGame {
 				msg = gameInProgressWarning()
 			} else {
-				state.deck = playingcards.NewDeck(state.includeJokers)
-				msg = "Cards have been reset."
+				channel.setDeck(state.NewDeck())
+				state.save()
+				if state.numDecks > 1 {
+					msg = fmt.Sprintf("Cards have been reset to a shoe of %d decks.", state.numDecks)
+				} else {
+					msg = "Cards have been reset."
+				}
 			}
+			state.mu.Unlock()
 
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -225,55 +457,176 @@ var (
 				},
 			})
 		},
-		"draw": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"draw": func(s Session, i *discordgo.InteractionCreate) {
+			count := 1
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "count" {
+					count = int(opt.IntValue())
+				}
+			}
+
 			state := GetServerState(i.GuildID)
-			if state.GameType() != NoGame {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseChannelMessageWithSource,
-					Data: &discordgo.InteractionResponseData{
-						Content: gameInProgressWarning(),
-					},
-				})
+			state.mu.Lock()
+			var data *discordgo.InteractionResponseData
+			if count == 1 {
+				data = drawCardResponse(state, state.Channel(i.ChannelID), interactionUserID(i))
 			} else {
-				cardDrawn := state.deck.DrawCard()
-				if strings.Contains(cardDrawn.String(), "Invalid") {
-					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Content: "No more cards left!",
-						},
-					})
-				} else {
-					cardURL := GetCardURL(cardDrawn, state.cardsStyle)
-					message := &discordgo.MessageEmbed{
-						Color: 0x7fb2f0,
-						Title: cardDrawn.String(),
-						Footer: &discordgo.MessageEmbedFooter{
-							Text: fmt.Sprintf("%d cards remaining.", state.deck.Size()),
-						},
-						Image: &discordgo.MessageEmbedImage{
-							URL: cardURL,
-						},
-					}
-					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Embeds: []*discordgo.MessageEmbed{message},
-						},
-					})
+				data = drawCardsResponse(state, state.Channel(i.ChannelID), interactionUserID(i), count)
+			}
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: data,
+			})
+		},
+		"deal": func(s Session, i *discordgo.InteractionCreate) {
+			players := []string{}
+			count := 0
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "players" {
+					players = parseMentions(opt.StringValue())
+				} else if opt.Name == "count" {
+					count = int(opt.IntValue())
+				}
+			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			msg := dealCards(state, state.Channel(i.ChannelID), players, count)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: msg,
+				},
+			})
+		},
+		"hand": func(s Session, i *discordgo.InteractionCreate) {
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			data := handResponse(state, state.Channel(i.ChannelID), interactionUserID(i))
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: data,
+			})
+		},
+		"play": func(s Session, i *discordgo.InteractionCreate) {
+			card := ""
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "card" {
+					card = opt.StringValue()
+				}
+			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			data := playCardResponse(state, state.Channel(i.ChannelID), interactionUserID(i), card)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: data,
+			})
+		},
+		"discard": func(s Session, i *discordgo.InteractionCreate) {
+			cards := ""
+			reshuffle := false
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "cards" {
+					cards = opt.StringValue()
+				} else if opt.Name == "reshuffle" {
+					reshuffle = opt.BoolValue()
+				}
+			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			msg := discardCards(state, state.Channel(i.ChannelID), interactionUserID(i), cards, reshuffle)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: msg,
+				},
+			})
+		},
+		"peek": func(s Session, i *discordgo.InteractionCreate) {
+			count := 1
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "count" {
+					count = int(opt.IntValue())
+				}
+			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			data := peekResponse(state, state.Channel(i.ChannelID), count)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: data,
+			})
+		},
+		"cut": func(s Session, i *discordgo.InteractionCreate) {
+			position := 0
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "position" {
+					position = int(opt.IntValue())
 				}
 			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			msg := cutDeck(state, state.Channel(i.ChannelID), position)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: msg,
+				},
+			})
 		},
-		"quit-game": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"deck-status": func(s Session, i *discordgo.InteractionCreate) {
+			fullListing := false
+			for _, opt := range i.ApplicationCommandData().Options {
+				if opt.Name == "full-listing" {
+					fullListing = opt.BoolValue()
+				}
+			}
+			if fullListing && !isModerator(i) {
+				respondEphemeral(s, i, "Only members who can manage the server can see the full listing of the deck.")
+				return
+			}
+
+			state := GetServerState(i.GuildID)
+			state.mu.Lock()
+			data := deckStatusResponse(state, state.Channel(i.ChannelID), fullListing)
+			state.mu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: data,
+			})
+		},
+		"quit-game": func(s Session, i *discordgo.InteractionCreate) {
 			msg := ""
 			state := GetServerState(i.GuildID)
-			if state.GameType() == NoGame {
-				msg = "There is no game in progress."
+			state.mu.Lock()
+			channel := state.Channel(i.ChannelID)
+			if channel.GameType() == NoGame {
+				msg = "There is no game in progress in this channel."
 			} else {
-				state.game.gameType = NoGame
-				state.deck = playingcards.NewDeck(state.includeJokers)
+				resetState(state, channel)
 				msg = "Stopped the game."
 			}
+			state.mu.Unlock()
 
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -282,7 +635,7 @@ var (
 				},
 			})
 		},
-		"set-style": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"set-style": func(s Session, i *discordgo.InteractionCreate) {
 			options := i.ApplicationCommandData().Options
 			optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
 			for _, opt := range options {
@@ -302,7 +655,26 @@ var (
 				},
 			})
 		},
-		"include-jokers": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"set-decks": func(s Session, i *discordgo.InteractionCreate) {
+			options := i.ApplicationCommandData().Options
+			optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+			for _, opt := range options {
+				optionMap[opt.Name] = opt
+			}
+
+			msg := "No change was made."
+			if option, ok := optionMap["count"]; ok {
+				msg = setDeckCount(i.GuildID, int(option.IntValue()))
+			}
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: msg,
+				},
+			})
+		},
+		"include-jokers": func(s Session, i *discordgo.InteractionCreate) {
 			options := i.ApplicationCommandData().Options
 			optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
 			for _, opt := range options {
@@ -324,21 +696,133 @@ var (
 	}
 )
 
+// Message component and modal handlers, keyed by the part of the custom ID before the first ":".
+// Games add theirs with RegisterGame.
+var componentHandlers = make(map[string]func(s Session, i *discordgo.InteractionCreate))
+
+// Handlers for the old "$pcb" text commands, keyed by the command without the prefix
+var messageHandlers = make(map[string]func(s Session, m *discordgo.MessageCreate))
+
 // End of slash commands setup
 
+// drawCardResponse draws a card from the channel's deck into the player's zone, and returns the message showing it.
+// The state must be locked.
+func drawCardResponse(state *ServerState, channel *ChannelState, userID string) *discordgo.InteractionResponseData {
+	if channel.GameType() != NoGame {
+		return &discordgo.InteractionResponseData{
+			Content: gameInProgressWarning(),
+		}
+	}
+	cardDrawn, err := channel.deck.Draw()
+	if err == playingcards.ErrEmptyDeck {
+		return &discordgo.InteractionResponseData{
+			Content: "No more cards left!",
+		}
+	}
+	channel.zones.Zone(playingcards.PlayerZone(userID)).Put(cardDrawn)
+	state.save()
+	cardURL := GetCardURL(cardDrawn, state.cardsStyle)
+	footer := fmt.Sprintf("%d cards remaining.", channel.deck.Size())
+	if channel.deck.NumDecks() > 1 {
+		footer = fmt.Sprintf("From deck %d of %d. %s", cardDrawn.DeckIndex()+1, channel.deck.NumDecks(), footer)
+	}
+	footer += cutCardNotice(channel)
+	message := &discordgo.MessageEmbed{
+		Color: 0x7fb2f0,
+		Title: cardDrawn.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: footer,
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: cardURL,
+		},
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+	}
+}
+
+// drawCardsResponse draws several cards from the channel's deck into the player's zone,
+// and returns a message showing them in one image. The state must be locked.
+func drawCardsResponse(state *ServerState, channel *ChannelState, userID string, count int) *discordgo.InteractionResponseData {
+	if channel.GameType() != NoGame {
+		return &discordgo.InteractionResponseData{
+			Content: gameInProgressWarning(),
+		}
+	}
+	cards, err := channel.zones.Deal(&channel.deck, playingcards.PlayerZone(userID), count)
+	if err != nil {
+		msg := err.Error()
+		if err == playingcards.ErrNotEnoughCards {
+			msg = fmt.Sprintf("Can't draw %d cards, only %d cards are left in the deck.", count, channel.deck.Size())
+			if channel.deck.Size() == 0 {
+				msg = "No more cards left!"
+			}
+		}
+		return &discordgo.InteractionResponseData{
+			Content: msg,
+		}
+	}
+	state.save()
+
+	// List the cards as text too, in case the image can't be shown
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.Glyph() + " " + card.String()
+	}
+	message := &discordgo.MessageEmbed{
+		Color:       0x7fb2f0,
+		Title:       fmt.Sprintf("Drew %d cards", len(cards)),
+		Description: strings.Join(names, ", "),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d cards remaining.", channel.deck.Size()) + cutCardNotice(channel),
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: GetHandURL(cards, state.cardsStyle, LayoutRow),
+		},
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{message},
+	}
+}
+
+// cutCardNotice returns the footer text that warns the cut card was reached, if it was. The state must be locked.
+func cutCardNotice(channel *ChannelState) string {
+	if channel.deck.CutCardReached() {
+		return " The cut card has been reached, use /reset-cards to reshuffle the shoe."
+	}
+	return ""
+}
+
 func getInfoText() string {
 	// Copied from message listener
 	var infoString strings.Builder
-	infoString.WriteString("This bot allows users to play with a standard 52-card deck of playing cards.\n\n")
-	infoString.WriteString("**/draw**: Draw a card from the current deck.\n")
+	infoString.WriteString("This bot allows users to play with a standard 52-card deck of playing cards. Each channel has its own deck and can run its own game.\n\n")
+	infoString.WriteString(fmt.Sprintf("**/draw**: Draw a card from the current deck, or up to %d cards at once with the count option.\n", MaxDrawCount))
+	infoString.WriteString("**/deal**: Deal cards from the deck to the mentioned players' hands.\n")
+	infoString.WriteString("**/hand**: Privately see the cards in your hand.\n")
+	infoString.WriteString("**/play**: Show a card from your hand to the channel.\n")
+	infoString.WriteString("**/discard**: Put cards you drew onto the discard pile, or shuffle the discard pile back into the deck.\n")
+	infoString.WriteString(fmt.Sprintf("**/peek**: Secretly look at up to %d cards from the top of the deck.\n", MaxPeekCount))
+	infoString.WriteString("**/cut**: Cut the deck, moving cards from the top to the bottom.\n")
 	infoString.WriteString("**/shuffle**: Shuffle the current deck of cards.\n")
+	infoString.WriteString("**/deck-status**: Show what is left in the deck, what was drawn from it, and the deck settings.\n")
 	infoString.WriteString("**/reset-cards**: Make a brand new, ordered deck of 52 cards.\n")
 	infoString.WriteString("**/set-style**: Change the style of the cards. Options are \"normal\" and \"pixel\".\n")
+	infoString.WriteString("**/set-decks**: Set how many decks are shuffled together into the shoe (1-8).\n")
 	infoString.WriteString("**/include-jokers**: Add or remove the Joker cards from the deck.\n")
 
 	infoString.WriteString("\n__**Games**__\n")
-	infoString.WriteString(fmt.Sprintf("**%shigh_or_low**: Start a game of High or Low (Will be reworked in the future).\n", prefix))
-	infoString.WriteString("**/quit-game**: Stop the currently running game.\n")
+	for _, info := range registeredGames() {
+		if info.Command != nil {
+			infoString.WriteString(fmt.Sprintf("**/%s**: %s\n", info.Command.Name, info.Command.Description))
+		}
+		for _, extra := range info.ExtraCommands {
+			infoString.WriteString(fmt.Sprintf("**/%s**: %s\n", extra.Command.Name, extra.Command.Description))
+		}
+	}
+	infoString.WriteString("**/quit-game**: Stop the game running in this channel.\n")
+	infoString.WriteString("**/verify**: Check that the last provably fair game in this channel was shuffled from the seeds it revealed.\n")
 
 	return infoString.String()
 }
@@ -347,6 +831,8 @@ func getInfoText() string {
 func setCardsStyle(guildID string, style string) string {
 	msg := "No change was made."
 	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
 	newStyle := strings.ToLower(style)
 	if newStyle == "normal" {
 		state.cardsStyle = KenneyLarge
@@ -355,17 +841,24 @@ func setCardsStyle(guildID string, style string) string {
 		state.cardsStyle = KenneyPixel
 		msg = "Changed cards to pixel style."
 	}
+	state.save()
 	return msg
 }
 
+// serverCardsStyle returns the art style of the cards used in the given server
+func serverCardsStyle(guildID string) int {
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.cardsStyle
+}
+
 // Toggle whether the Joker cards should be in the deck of cards or not, and return a status message in response.
 func toggleJokerCards(guildID string, toggle bool) string {
 	msg := "No change was made."
 	state := GetServerState(guildID)
-
-	if state.GameType() != NoGame {
-		return gameInProgressWarning()
-	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
 
 	if toggle && state.includeJokers {
 		return "There are already Joker cards in the deck."
@@ -374,22 +867,59 @@ func toggleJokerCards(guildID string, toggle bool) string {
 	}
 
 	state.includeJokers = toggle
-	state.deck = playingcards.NewDeck(state.includeJokers)
+	state.resetIdleDecks()
+	state.save()
 
 	if toggle {
-		msg = "Added Joker cards and reset the deck."
+		msg = "Added Joker cards and reset the decks."
 	} else {
-		msg = "Removed Joker cards and reset the deck."
+		msg = "Removed Joker cards and reset the decks."
 	}
 	return msg
 }
 
+// Change the number of decks used by the server's shoe, and return a status message in response.
+func setDeckCount(guildID string, count int) string {
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if count < 1 || count > MaxDecks {
+		return fmt.Sprintf("The number of decks must be between 1 and %d.", MaxDecks)
+	}
+	if count == state.numDecks {
+		return fmt.Sprintf("The shoe already uses %d deck(s).", count)
+	}
+
+	state.numDecks = count
+	state.resetIdleDecks()
+	state.save()
+
+	if count == 1 {
+		return "Now using a single deck and reset the cards."
+	}
+	return fmt.Sprintf("Now using a shoe of %d decks and reset the cards.", count)
+}
+
 func main() {
-	rand.Seed(time.Now().Unix())
+	parseFlags()
+	if *CryptoShuffle {
+		playingcards.SetDefaultShuffler(playingcards.NewCryptoShuffler())
+	} else if *ShuffleSeed != 0 {
+		log.Printf("Shuffling decks with seed %d", *ShuffleSeed)
+		playingcards.SetDefaultShuffler(playingcards.NewSeededShuffler(*ShuffleSeed))
+	} else {
+		rand.Seed(time.Now().UnixNano())
+	}
+	if len(DataDir) > 0 {
+		storage = NewFileStorage(DataDir)
+	}
 
 	mainServer := http.NewServeMux()
 	mainServer.Handle("/", http.FileServer(http.Dir("./public")))
 	mainServer.Handle("/card_images/", http.StripPrefix("/card_images/", http.FileServer(http.Dir("./card_images"))))
+	mainServer.HandleFunc("/hands/", handImageHandler)
+	mainServer.HandleFunc("/klondike/", klondikeImageHandler)
 
 	go startServer(mainServer)
 
@@ -401,15 +931,17 @@ func main() {
 	}
 
 	// Listen for MessageCreate events.
-	dg.AddHandler(messageCreate)
-	// Listen for MessageReactionAdd events
-	dg.AddHandler(messageReactionAdd)
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		// Ignore all messages created by the bot itself
+		if m.Author.ID == s.State.User.ID {
+			return
+		}
+		messageCreate(s, m)
+	})
 
 	// Set up slash commands
 	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
-			h(s, i)
-		}
+		interactionCreate(s, i)
 	})
 	registeredCommands := make([]*discordgo.ApplicationCommand, len(commands))
 	for i, v := range commands {
@@ -421,7 +953,7 @@ func main() {
 	}
 
 	// In this example, we only care about receiving message events.
-	dg.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuildMessages | discordgo.IntentsGuildMessageReactions)
+	dg.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuildMessages)
 
 	err = dg.Open()
 	if err != nil {
@@ -429,6 +961,15 @@ func main() {
 		return
 	}
 
+	// Games can't be resumed after a restart, so let their players know they were stopped
+	for _, game := range interruptedGames() {
+		state := GetServerState(game.guildID)
+		state.mu.Lock()
+		state.save()
+		state.mu.Unlock()
+		dg.ChannelMessageSend(game.channelID, fmt.Sprintf("The bot was restarted, so the game of %s in progress was stopped.", gameName(game.gameType)))
+	}
+
 	// Wait here until CTRL-C or other term signal is received.
 	fmt.Println("Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
@@ -451,13 +992,32 @@ func main() {
 
 // GetServerState looks for the given server and returns it if it exists, or creates a new entry first
 func GetServerState(guildID string) *ServerState {
+	serverStatesMu.Lock()
+	defer serverStatesMu.Unlock()
 	state, exists := serverStates[guildID]
 	if !exists {
-		// Add the server to the list of servers
-		state = NewServerState(guildID)
+		// Add the server to the list of servers, restoring it from storage if it was saved before
+		var stored *StoredServerState
+		var err error
+		if storage != nil {
+			stored, err = storage.Load(guildID)
+			if err != nil {
+				log.Printf("Error loading the state of server %s: %v", guildID, err)
+			}
+		}
+		if stored != nil {
+			state = restoreServerState(stored)
+		} else {
+			state = NewServerState(guildID)
+		}
+		if err != nil {
+			// Keep the unreadable state instead of replacing it with a fresh one on the next save
+			if err := storage.SetAside(guildID); err != nil {
+				log.Printf("Error setting aside the state of server %s, it won't be saved: %v", guildID, err)
+				state.unsaved = true
+			}
+		}
 		serverStates[guildID] = state
-		// Initialize the server's deck of cards
-		state.deck = playingcards.NewDeck(state.includeJokers)
 	}
 	return state
 }
@@ -469,10 +1029,14 @@ func GetCardPath(card playingcards.Card, style int) string {
 	path := ""
 
 	if card.NumberAsString() == "Joker" {
+		color, err := card.Suit().Color()
+		if err != nil {
+			return ""
+		}
 		if style == KenneyLarge {
-			path = fmt.Sprintf("card_images/kenney_cards_large/cardJoker%s.png", card.Color())
+			path = fmt.Sprintf("card_images/kenney_cards_large/cardJoker%s.png", color)
 		} else if style == KenneyPixel {
-			path = fmt.Sprintf("card_images/kenney_cards_pixel/card_joker_%s.png", strings.ToLower(card.Color()))
+			path = fmt.Sprintf("card_images/kenney_cards_pixel/card_joker_%s.png", strings.ToLower(color))
 		}
 		return path
 	}
@@ -508,276 +1072,121 @@ func GetCardPath(card playingcards.Card, style int) string {
 	return path
 }
 
+// GetCardBackPath returns the path to the image of the back of a card in the given style
+func GetCardBackPath(style int) string {
+	if style == KenneyPixel {
+		return "card_images/kenney_cards_pixel/card_back.png"
+	}
+	return "card_images/kenney_cards_large/cardBack_blue4.png"
+}
+
 // GetCardURL returns the full url to the image for the given card
 func GetCardURL(card playingcards.Card, style int) string {
 	cardPath := GetCardPath(card, style)
-	// URL of the server hosting the images
+	cardURL := fmt.Sprintf("%s/%s", hostURL(), cardPath)
+	return cardURL
+}
+
+// hostURL returns the URL of the server hosting the images
+func hostURL() string {
 	hostURL := os.Getenv("HOST_URL")
 	if len(hostURL) == 0 {
 		hostURL = "http://localhost:8080"
 	}
-	cardURL := fmt.Sprintf("%s/%s", hostURL, cardPath)
-	return cardURL
+	return hostURL
 }
 
-func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Ignore all messages created by the bot itself
-	if m.Author.ID == s.State.User.ID {
-		return
-	}
+// messageCreate handles the old "$pcb" text commands. Messages sent by the bot itself must be filtered out first.
+func messageCreate(s Session, m *discordgo.MessageCreate) {
 	// Check for the prefix string
 	if !strings.HasPrefix(m.Content, prefix) {
 		return
 	}
 	command := strings.TrimPrefix(m.Content, prefix)
 
-	// TODO: Relies on message reactions. Switch to using buttons instead.
-	if command == "high_or_low" {
-		state := GetServerState(m.GuildID)
-		if state.GameType() != NoGame {
-			s.ChannelMessageSend(m.ChannelID, gameInProgressWarning())
-			return
+	if h, ok := messageHandlers[command]; ok {
+		h(s, m)
+	}
+}
+
+// interactionCreate sends slash commands, button presses and modal submissions to their handlers
+func interactionCreate(s Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
+			h(s, i)
 		}
-		message := &discordgo.MessageEmbed{
-			Color:       0x3dbb6b,
-			Title:       "High or Low",
-			Description: "Guess whether the next card will be higher or lower.\nReact with 🎲 to join.\nOnly your first reaction in each round will be counted, so choose carefully!",
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "Game starting in 7 seconds...",
-			},
+	case discordgo.InteractionMessageComponent:
+		if h, ok := componentHandlers[customIDPrefix(i.MessageComponentData().CustomID)]; ok {
+			h(s, i)
 		}
-		messageObj, err := s.ChannelMessageSendEmbed(m.ChannelID, message)
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, "Error when trying to start the game.")
-			return
+	case discordgo.InteractionModalSubmit:
+		if h, ok := componentHandlers[customIDPrefix(i.ModalSubmitData().CustomID)]; ok {
+			h(s, i)
 		}
-		s.MessageReactionAdd(m.ChannelID, messageObj.ID, "\xf0\x9f\x8e\xb2")
-		state.game.lastMessageID = messageObj.ID
-		go HighOrLowGame(state, s, m.ChannelID)
 	}
 }
 
 func gameInProgressWarning() string {
-	return fmt.Sprintf("A game is currently in progress! Use `/quit-game` to stop the game.")
+	return fmt.Sprintf("A game is currently in progress in this channel! Use `/quit-game` to stop the game.")
 }
 
-func messageReactionAdd(s *discordgo.Session, m *discordgo.MessageReactionAdd) {
-	state := GetServerState(m.GuildID)
-	if state.GameType() == NoGame {
-		// Reactions are used in games only
-		return
-	}
-	if m.MessageReaction.UserID == s.State.User.ID || state.game.channelID != m.ChannelID {
-		return
-	}
-
-	if state.game.lastMessageID == m.MessageID {
-		reactionName := m.MessageReaction.Emoji.APIName()
-
-		if reactionName == "🎲" && state.game.preStartPhase {
-			_, ok := state.Players()[m.MessageReaction.UserID]
-			// Add new players to the game
-			if !ok {
-				state.Players()[m.MessageReaction.UserID] = &PlayerState{active: true}
-				return
-			}
-			return
-		}
-
-		guess := NoGuess
-		if reactionName == "⬆️" {
-			guess = High
-		} else if reactionName == "⬇️" {
-			guess = Low
-		} else {
-			// Ignore all other reactions
-			return
-		}
-		// Update the player's state based on their guess
-		playerState, ok := state.Players()[m.MessageReaction.UserID]
-		if ok {
-			// Check if the player is still in the game and has not guessed this round yet
-			if playerState.Active() && playerState.choice == NoGuess {
-				playerState.choice = guess
-				state.Players()[m.MessageReaction.UserID] = playerState
-			}
-		}
-	}
+// customIDPrefix returns the part of a component's custom ID that selects its handler
+func customIDPrefix(customID string) string {
+	return strings.SplitN(customID, ":", 2)[0]
 }
 
-// HighOrLowGame starts a new game of High or Low for the given Discord server in the channel the bot responded to.
-func HighOrLowGame(state *ServerState, s *discordgo.Session, channelID string) {
-	state.game.gameType = HighOrLow
-	state.deck = playingcards.NewDeck(false) // High or Low does not use Joker cards
-	state.deck.Shuffle()
-	state.game.channelID = channelID
-	state.game.preStartPhase = true
-	time.Sleep(7 * time.Second)
-	state.game.preStartPhase = false
-
-	// Check if any players have joined and the game did not exit
-	if len(state.Players()) == 0 || state.game.gameType != HighOrLow {
-		if len(state.Players()) == 0 && state.game.gameType == HighOrLow {
-			s.ChannelMessageSend(channelID, "Nobody joined!")
-		}
-		resetState(state)
-		return
+// interactionUserID returns the ID of the user who triggered an interaction
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
 	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
 
-	// Set up the game state
-	state.game.channelID = channelID
-	cardDrawn := state.deck.DrawCard()
-	numPlayers := len(state.Players())
-	numRounds := 0
-
-	// Game loop
-	for {
-		if state.game.gameType != HighOrLow {
-			resetState(state)
-			return
-		}
-		// Show the current card, add up/down reactions, then wait 5 seconds
-		cardURL := GetCardURL(cardDrawn, state.cardsStyle)
-		message := &discordgo.MessageEmbed{
-			Color: 0x3dbb6b,
-			Title: cardDrawn.String(),
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: fmt.Sprintf("%d cards remaining.", state.deck.Size()),
-			},
-			Image: &discordgo.MessageEmbedImage{
-				URL: cardURL,
-			},
-		}
-		messageObj, err := s.ChannelMessageSendEmbed(channelID, message)
-		if err != nil {
-			// Something went wrong, stop the game
-			resetState(state)
-			s.ChannelMessageSend(channelID, "Error found while running the game. Exiting...")
-			return
-		}
-		s.MessageReactionAdd(channelID, messageObj.ID, "\xe2\xac\x86\xef\xb8\x8f")
-		s.MessageReactionAdd(channelID, messageObj.ID, "\xe2\xac\x87\xef\xb8\x8f")
-		state.game.lastMessageID = messageObj.ID
-
-		time.Sleep(5 * time.Second)
-
-		if state.game.gameType != HighOrLow {
-			// The bot quit the game
-			resetState(state)
-			return
-		}
-
-		// Check all players who have reacted, remove wrong responses
-		lastCardValue := cardDrawn.Value()
-		cardDrawn = state.deck.DrawCard()
-		correctGuess := NoGuess // Default is a tie
-		guessString := ""
-		if cardDrawn.Value() < lastCardValue {
-			correctGuess = Low
-			guessString = "lower"
-		} else if cardDrawn.Value() > lastCardValue {
-			correctGuess = High
-			guessString = "higher"
-		}
-
-		if correctGuess == NoGuess {
-			// The new card was neither higher nor lower, nobody is eliminated
-			s.ChannelMessageSend(channelID, "Draw! Nobody was eliminated.")
-			for _, playerState := range state.Players() {
-				// Make sure to reset the players' choices
-				if playerState.Active() {
-					playerState.choice = NoGuess
-				}
-			}
-		} else {
-			eliminatedPlayers := []string{}
-			// Iterate through all active players, removing those who made the wrong guess
-			for player, playerState := range state.Players() {
-				if playerState.Active() && playerState.choice != correctGuess {
-					playerState.active = false
-					eliminatedPlayers = append(eliminatedPlayers, player)
-				}
-				// Make sure to reset the player's choice
-				playerState.choice = NoGuess
-			}
-			noMorePlayers := len(eliminatedPlayers) >= numPlayers
-			// List the players eliminated this round
-			var eliminatedMessage strings.Builder
-			eliminatedMessage.WriteString(fmt.Sprintf("%s. The next card was %s!\n", cardDrawn.String(), guessString))
-			if len(eliminatedPlayers) == 0 {
-				eliminatedMessage.WriteString("No players eliminated.")
-			} else {
-				eliminatedMessage.WriteString("Players eliminated this round: ")
-				for _, player := range eliminatedPlayers {
-					// If these players were the last ones eliminated, revert their active status (making them winners)
-					if noMorePlayers {
-						state.Players()[player].active = true
-					}
-					member, err := s.GuildMember(state.id, player)
-					if err != nil {
-						continue
-					}
-					eliminatedMessage.WriteString(fmt.Sprintf("%s ", member.Mention()))
-				}
-			}
-			s.ChannelMessageSend(channelID, eliminatedMessage.String())
-
-			numPlayers -= len(eliminatedPlayers)
-		}
-
-		if numPlayers <= 0 {
-			break
+// interactionUserName returns the name the user who triggered an interaction goes by in the server
+func interactionUserName(i *discordgo.InteractionCreate) string {
+	if i.Member != nil {
+		if i.Member.Nick != "" {
+			return i.Member.Nick
 		}
-
-		numRounds++
-
-		if state.deck.Size() == 0 {
-			// Ran out of cards, end the game
-			s.ChannelMessageSend(channelID, "No more cards left!")
-			break
+		if i.Member.User != nil {
+			return i.Member.User.Username
 		}
 	}
-
-	// Print the last card of the game
-	cardURL := GetCardURL(cardDrawn, state.cardsStyle)
-	message := &discordgo.MessageEmbed{
-		Color: 0x3dbb6b,
-		Title: fmt.Sprintf("Last card drawn: %s", cardDrawn.String()),
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("%d cards remained.", state.deck.Size()),
-		},
-		Image: &discordgo.MessageEmbedImage{
-			URL: cardURL,
-		},
+	if i.User != nil {
+		return i.User.Username
 	}
-	s.ChannelMessageSendEmbed(channelID, message)
+	return ""
+}
 
-	// List the winners
-	var winnersMessage strings.Builder
-	roundString := "rounds"
-	if numRounds == 1 {
-		roundString = "round"
-	}
-	winnersMessage.WriteString(fmt.Sprintf("Game end! Congrats to the following players who lasted the most rounds! (%d %s)\n", numRounds, roundString))
-	for player, playerState := range state.Players() {
-		if playerState.Active() {
-			member, err := s.GuildMember(state.id, player)
-			if err != nil {
-				continue
-			}
-			winnersMessage.WriteString(fmt.Sprintf("%s ", member.Mention()))
-		}
-	}
-	s.ChannelMessageSend(channelID, winnersMessage.String())
+// mention returns the string that mentions the given user in a message
+func mention(userID string) string {
+	return fmt.Sprintf("<@%s>", userID)
+}
 
-	// Reset game state
-	resetState(state)
+// respondEphemeral replies to an interaction with a message only the user who triggered it can see
+func respondEphemeral(s Session, i *discordgo.InteractionCreate, msg string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
 }
 
-func resetState(state *ServerState) {
-	state.game.gameType = NoGame
-	state.game.channelID = ""
-	state.game.lastMessageID = ""
-	state.players = make(map[string]*PlayerState)
-	state.deck = playingcards.NewDeck(state.includeJokers)
+// resetState stops any game running in the channel and gives it a new deck. The state must be locked.
+func resetState(state *ServerState, channel *ChannelState) {
+	if channel.game.current != nil {
+		channel.game.current.Lock()
+		channel.game.current.End()
+		channel.game.current.Unlock()
+	}
+	channel.game = GameState{gameType: NoGame}
+	channel.setDeck(state.NewDeck())
+	state.save()
 }
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+var guildCount int64
+
+// testGuild returns a guild ID no other test has used, so every test starts with fresh server states
+func testGuild(name string) string {
+	return fmt.Sprintf("%s-%d", name, atomic.AddInt64(&guildCount, 1))
+}
+
+// intOption builds an integer option of a slash command
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+// stringOption builds a string option of a slash command
+func stringOption(name string, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// boolOption builds a boolean option of a slash command
+func boolOption(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+// messageText returns the content and embeds of a message as one string, to search for expected text
+func messageText(content string, embeds []*discordgo.MessageEmbed) string {
+	var text strings.Builder
+	text.WriteString(content)
+	for _, embed := range embeds {
+		text.WriteString("\n" + embed.Title + "\n" + embed.Description)
+		for _, field := range embed.Fields {
+			text.WriteString("\n" + field.Name + "\n" + field.Value)
+		}
+	}
+	return text.String()
+}
+
+// responseText returns the text of the last response to an interaction
+func responseText(t *testing.T, f *FakeSession, i *discordgo.InteractionCreate) string {
+	t.Helper()
+	responses := f.Responses(i)
+	if len(responses) == 0 {
+		t.Fatal("the interaction got no response")
+	}
+	data := responses[len(responses)-1].Data
+	if data == nil {
+		return ""
+	}
+	return messageText(data.Content, data.Embeds)
+}
+
+// ephemeralMessage returns the ephemeral message the last response to an interaction showed, so its menus can be used
+func ephemeralMessage(t *testing.T, f *FakeSession, i *discordgo.InteractionCreate) *discordgo.Message {
+	t.Helper()
+	responses := f.Responses(i)
+	if len(responses) == 0 || responses[len(responses)-1].Data == nil {
+		t.Fatal("the interaction got no message in response")
+	}
+	data := responses[len(responses)-1].Data
+	return &discordgo.Message{
+		ID:         "ephemeral-" + i.ID,
+		ChannelID:  i.ChannelID,
+		Content:    data.Content,
+		Embeds:     data.Embeds,
+		Components: data.Components,
+		Flags:      discordgo.MessageFlagsEphemeral,
+	}
+}
+
+// selectMenu returns the select menu with the given custom ID on a message, or nil if it has none
+func selectMenu(message *discordgo.Message, customID string) *discordgo.SelectMenu {
+	for _, row := range message.Components {
+		actions, ok := row.(discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if menu, ok := component.(discordgo.SelectMenu); ok && strings.HasPrefix(menu.CustomID, customID) {
+				return &menu
+			}
+		}
+	}
+	return nil
+}
+
+// hasButton returns whether a message has a button with the given custom ID
+func hasButton(message *discordgo.Message, customID string) bool {
+	for _, row := range message.Components {
+		actions, ok := row.(discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if button, ok := component.(discordgo.Button); ok && button.CustomID == customID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lastMessage returns the last message the bot sent to a channel
+func lastMessage(t *testing.T, f *FakeSession, channelID string) *discordgo.Message {
+	t.Helper()
+	messages := f.Messages(channelID)
+	if len(messages) == 0 {
+		t.Fatalf("no messages in %s", channelID)
+	}
+	return messages[len(messages)-1]
+}
+
+// waitForNoGame waits until the game in a channel is over and the channel was reset, so the reset doesn't
+// save the server state while a later test swaps the storage
+func waitForNoGame(t *testing.T, guildID string, channelID string) {
+	t.Helper()
+	waitFor(t, "the channel to be free", func() bool {
+		state := GetServerState(guildID)
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		return state.Channel(channelID).GameType() == NoGame
+	})
+}
+
+// waitFor polls until done returns true, failing the test after a few seconds
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// TestEveryCommand runs each slash command the bot registers in a fresh channel and checks its response
+func TestEveryCommand(t *testing.T) {
+	tests := map[string]struct {
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		want    string
+	}{
+		"info":           {nil, "Playing Cards Bot Info"},
+		"shuffle":        {nil, "Cards shuffled!"},
+		"reset-cards":    {nil, "Cards have been reset."},
+		"quit-game":      {nil, "There is no game in progress in this channel."},
+		"draw":           {[]*discordgo.ApplicationCommandInteractionDataOption{intOption("count", 3)}, "Drew 3 cards"},
+		"deal":           {[]*discordgo.ApplicationCommandInteractionDataOption{stringOption("players", "<@101> <@102>"), intOption("count", 5)}, "Dealt 5 cards"},
+		"hand":           {nil, "You have no cards."},
+		"play":           {[]*discordgo.ApplicationCommandInteractionDataOption{stringOption("card", "AS")}, "You don't have the Ace of Spades."},
+		"discard":        {nil, "You have no cards to discard."},
+		"peek":           {[]*discordgo.ApplicationCommandInteractionDataOption{intOption("count", 2)}, "Top 2 cards of the deck"},
+		"cut":            {[]*discordgo.ApplicationCommandInteractionDataOption{intOption("position", 10)}, "moving the top 10 cards to the bottom"},
+		"deck-status":    {nil, "52 cards left in the deck, 0 drawn"},
+		"set-style":      {[]*discordgo.ApplicationCommandInteractionDataOption{stringOption("style", "pixel")}, "Changed cards to pixel style."},
+		"set-decks":      {[]*discordgo.ApplicationCommandInteractionDataOption{intOption("count", 2)}, "shoe of 2 decks"},
+		"include-jokers": {[]*discordgo.ApplicationCommandInteractionDataOption{boolOption("include-jokers", true)}, "Added Joker cards"},
+		"verify":         {nil, "No provably fair game has finished in this channel yet."},
+		"move":           {[]*discordgo.ApplicationCommandInteractionDataOption{stringOption("from", "w"), stringOption("to", "f")}, "You don't have a game of Klondike in progress."},
+		"blackjack":      {nil, "Blackjack"},
+		"crazy-eights":   {nil, "Crazy Eights"},
+		"gofish":         {nil, "Go Fish"},
+		"hearts":         {nil, "Hearts"},
+		"high-or-low":    {nil, "High or Low"},
+		"holdem":         {nil, "Texas Hold'em"},
+		"klondike":       {nil, "Dealt a new game."},
+		"war":            {nil, "War"},
+	}
+
+	f := NewFakeSession()
+	guildID := testGuild("commands")
+	for _, command := range commands {
+		test, ok := tests[command.Name]
+		if !ok {
+			t.Errorf("no test for /%s", command.Name)
+			continue
+		}
+		// Each command is run by its own user, since games of Klondike follow the user across channels
+		i := f.Command(guildID, "channel-"+command.Name, "user-"+command.Name, command.Name, test.options...)
+		if text := responseText(t, f, i); !strings.Contains(text, test.want) {
+			t.Errorf("/%s responded %q, want it to contain %q", command.Name, text, test.want)
+		}
+	}
+	// Games refuse to start over another game in the same channel
+	i := f.Command(guildID, "channel-war", "101", "gofish")
+	if text := responseText(t, f, i); !strings.Contains(text, "game") {
+		t.Errorf("/gofish during a game of War responded %q", text)
+	}
+	// Nobody joined the game of High or Low, so it ends on its own and resets its channel in the background
+	waitForNoGame(t, guildID, "channel-high-or-low")
+}
+
+func TestDrawAndDeal(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("deck"), "table"
+	f.Command(guildID, channelID, "101", "draw", intOption("count", 5))
+	f.Command(guildID, channelID, "101", "deal", stringOption("players", "<@101> <@102>"), intOption("count", 3))
+
+	i := f.Command(guildID, channelID, "101", "deck-status")
+	if text := responseText(t, f, i); !strings.Contains(text, "41 cards left in the deck, 11 drawn") {
+		t.Errorf("/deck-status responded %q after drawing 11 cards", text)
+	}
+	i = f.Command(guildID, channelID, "102", "hand")
+	if text := responseText(t, f, i); !strings.Contains(text, "3 cards") {
+		t.Errorf("/hand responded %q after being dealt 3 cards", text)
+	}
+	i = f.Command(guildID, channelID, "101", "discard")
+	if text := responseText(t, f, i); !strings.Contains(text, "8") {
+		t.Errorf("/discard responded %q, want the 8 cards in the hand discarded", text)
+	}
+	i = f.Command(guildID, channelID, "101", "shuffle")
+	if text := responseText(t, f, i); text != "Cards shuffled!" {
+		t.Errorf("/shuffle responded %q", text)
+	}
+}
+
+// TestDeckStatusShowsDeckCount checks that /deck-status reports the decks in the channel's deck,
+// which a game like Blackjack may have built from a different number of decks than the server's setting
+func TestDeckStatusShowsDeckCount(t *testing.T) {
+	state := &ServerState{numDecks: 1}
+	channel := &ChannelState{deck: playingcards.NewShoe(3, false)}
+	for _, field := range deckStatusResponse(state, channel, false).Embeds[0].Fields {
+		if field.Name == "Settings" && !strings.Contains(field.Value, "Decks: 3") {
+			t.Errorf("/deck-status settings are %q for a shoe of 3 decks", field.Value)
+		}
+	}
+}
+
+// TestHandOverRenderLimit checks that a hand too big for one image is listed without a broken image link
+func TestHandOverRenderLimit(t *testing.T) {
+	state := &ServerState{}
+	channel := &ChannelState{}
+	for _, n := range []int{MaxRenderCount, MaxRenderCount + 1} {
+		channel.deck, channel.zones = playingcards.NewShoe(2, false), playingcards.Zones{}
+		if _, err := channel.zones.Deal(&channel.deck, playingcards.PlayerZone("101"), n); err != nil {
+			t.Fatal(err)
+		}
+		embed := handResponse(state, channel, "101").Embeds[0]
+		if hasImage := embed.Image != nil; hasImage != (n <= MaxRenderCount) {
+			t.Errorf("a hand of %d cards has an image: %v", n, hasImage)
+		}
+		if strings.Count(embed.Description, ",") != n-1 {
+			t.Errorf("a hand of %d cards isn't listed in full: %q", n, embed.Description)
+		}
+	}
+}
+
+// TestHighOrLowGame plays a whole game of High or Low with two players who always guess differently,
+// then checks the seeds revealed at the end with /verify
+func TestHighOrLowGame(t *testing.T) {
+	f := NewFakeSession()
+	guildID, channelID := testGuild("highorlow"), "table"
+	i := f.Command(guildID, channelID, "101", "high-or-low")
+	lobby, err := f.InteractionResponse(i.Interaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []string{"101", "102"} {
+		if text := responseText(t, f, f.Press(guildID, userID, lobby, "highorlow:join")); text != "You joined the game!" {
+			t.Fatalf("%s pressed Join and got %q", userID, text)
+		}
+	}
+	i = f.Submit(guildID, channelID, "102", "highorlow:seed_text", map[string]string{"seed": "lucky"})
+	if text := responseText(t, f, i); text != "Your seed will be mixed into the shuffle." {
+		t.Fatalf("adding a seed responded %q", text)
+	}
+
+	guessed := map[string]bool{}
+	over := func() bool {
+		for _, message := range f.Messages(channelID) {
+			if strings.HasPrefix(message.Content, "Game end!") {
+				return true
+			}
+			if hasButton(message, "highorlow:high") && !guessed[message.ID] {
+				guessed[message.ID] = true
+				f.Press(guildID, "101", message, "highorlow:high")
+				f.Press(guildID, "102", message, "highorlow:low")
+			}
+		}
+		return false
+	}
+	waitFor(t, "the game to end", over)
+	if len(guessed) == 0 {
+		t.Error("the game ended without a single round")
+	}
+	waitForNoGame(t, guildID, channelID)
+
+	i = f.Command(guildID, channelID, "103", "verify")
+	if text := responseText(t, f, i); !strings.Contains(text, "match") {
+		t.Errorf("/verify after the game responded %q", text)
+	}
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	proof := state.Channel(channelID).lastShuffle
+	state.mu.Unlock()
+	if proof == nil || proof.ClientSeed != "101,102|lucky" {
+		t.Errorf("the revealed shuffle is %+v, want the client seed made from the players and the added seed", proof)
+	}
+	i = f.Submit(guildID, channelID, "102", "highorlow:seed_text", map[string]string{"seed": "late"})
+	if text := responseText(t, f, i); text != "This game is already over." {
+		t.Errorf("adding a seed after the game responded %q", text)
+	}
+}
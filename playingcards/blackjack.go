@@ -0,0 +1,55 @@
+package playingcards
+
+// BlackjackValue returns how many points a single card is worth in blackjack, counting aces as 1.
+// Joker cards are worth nothing.
+func BlackjackValue(c Card) int {
+	switch {
+	case c.suit == RED_JOKER || c.suit == BLACK_JOKER:
+		return 0
+	case c.number >= 10:
+		return 10
+	default:
+		return c.number
+	}
+}
+
+// BlackjackTotal returns the best total of a blackjack hand, and whether the total is soft (an ace is counted as 11)
+func BlackjackTotal(cards []Card) (int, bool) {
+	total := 0
+	hasAce := false
+	for _, c := range cards {
+		total += BlackjackValue(c)
+		if c.number == 1 && c.suit <= SPADES {
+			hasAce = true
+		}
+	}
+	// At most one ace can ever count as 11 without busting
+	if hasAce && total+10 <= 21 {
+		return total + 10, true
+	}
+	return total, false
+}
+
+// IsBlackjack returns whether the cards are a natural blackjack: an ace and a ten-valued card
+func IsBlackjack(cards []Card) bool {
+	total, _ := BlackjackTotal(cards)
+	return len(cards) == 2 && total == 21
+}
+
+// IsBust returns whether the cards add up to more than 21
+func IsBust(cards []Card) bool {
+	total, _ := BlackjackTotal(cards)
+	return total > 21
+}
+
+// DealerShouldHit returns whether a blackjack dealer must take another card.
+// The dealer stands on 17 or more, except on a soft 17 when hitSoft17 is set.
+func DealerShouldHit(cards []Card, hitSoft17 bool) bool {
+	total, soft := BlackjackTotal(cards)
+	return total < 17 || (total == 17 && soft && hitSoft17)
+}
+
+// BlackjackTotal returns the best blackjack total of the hand, and whether it is soft
+func (h Hand) BlackjackTotal() (int, bool) {
+	return BlackjackTotal(h.cards)
+}
@@ -0,0 +1,86 @@
+package playingcards
+
+import "testing"
+
+func TestBlackjackTotal(t *testing.T) {
+	tests := []struct {
+		cards []string
+		total int
+		soft  bool
+	}{
+		{[]string{"AS", "KH"}, 21, true},
+		{[]string{"AS", "AH"}, 12, true},
+		{[]string{"AS", "AH", "AD"}, 13, true},
+		{[]string{"AS", "AH", "AD", "AC"}, 14, true},
+		{[]string{"AS", "AH", "9D"}, 21, true},
+		{[]string{"AS", "AH", "10D"}, 12, false},
+		{[]string{"AS", "6H", "KD"}, 17, false},
+		{[]string{"AS", "6H"}, 17, true},
+		{[]string{"QS", "JH", "2D"}, 22, false},
+		{[]string{"5C", "RJ", "7D"}, 12, false},
+		{[]string{}, 0, false},
+	}
+	for _, test := range tests {
+		total, soft := BlackjackTotal(parseCards(t, test.cards...))
+		if total != test.total || soft != test.soft {
+			t.Errorf("%v: got %d (soft %v), want %d (soft %v)", test.cards, total, soft, test.total, test.soft)
+		}
+	}
+}
+
+func TestIsBlackjack(t *testing.T) {
+	tests := []struct {
+		cards []string
+		want  bool
+	}{
+		{[]string{"AS", "KH"}, true},
+		{[]string{"10D", "AC"}, true},
+		{[]string{"AS", "5H", "5D"}, false},
+		{[]string{"KS", "QH"}, false},
+		{[]string{"AS", "AH"}, false},
+	}
+	for _, test := range tests {
+		if got := IsBlackjack(parseCards(t, test.cards...)); got != test.want {
+			t.Errorf("IsBlackjack(%v) = %v, want %v", test.cards, got, test.want)
+		}
+	}
+}
+
+func TestIsBust(t *testing.T) {
+	tests := []struct {
+		cards []string
+		want  bool
+	}{
+		{[]string{"KS", "QH", "2D"}, true},
+		{[]string{"KS", "QH", "AD"}, false},
+		{[]string{"AS", "AH", "KD", "QC"}, true},
+		{[]string{"9S", "7H", "5D"}, false},
+	}
+	for _, test := range tests {
+		if got := IsBust(parseCards(t, test.cards...)); got != test.want {
+			t.Errorf("IsBust(%v) = %v, want %v", test.cards, got, test.want)
+		}
+	}
+}
+
+func TestDealerShouldHit(t *testing.T) {
+	tests := []struct {
+		cards     []string
+		hitSoft17 bool
+		want      bool
+	}{
+		{[]string{"10S", "6H"}, false, true},
+		{[]string{"10S", "7H"}, false, false},
+		{[]string{"10S", "7H"}, true, false},
+		{[]string{"AS", "6H"}, false, false},
+		{[]string{"AS", "6H"}, true, true},
+		{[]string{"AS", "AH", "5D"}, true, true},
+		{[]string{"AS", "6H", "KD"}, true, false},
+		{[]string{"AS", "7H"}, true, false},
+	}
+	for _, test := range tests {
+		if got := DealerShouldHit(parseCards(t, test.cards...), test.hitSoft17); got != test.want {
+			t.Errorf("DealerShouldHit(%v, %v) = %v, want %v", test.cards, test.hitSoft17, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package playingcards
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -19,22 +21,51 @@ const (
 	BLACK_JOKER
 )
 
-func (s Suit) String() string {
+// ErrInvalidSuit is returned for a suit that is not one of the suit constants
+var ErrInvalidSuit = errors.New("a card's suit must be Clubs, Diamonds, Hearts, Spades, or a Joker")
+
+// Valid returns whether the suit is one of the suit constants
+func (s Suit) Valid() bool {
+	return s >= CLUBS && s <= BLACK_JOKER
+}
+
+// Name returns the suit's name, or ErrInvalidSuit
+func (s Suit) Name() (string, error) {
 	switch s {
 	case CLUBS:
-		return "Clubs"
+		return "Clubs", nil
 	case DIAMONDS:
-		return "Diamonds"
+		return "Diamonds", nil
 	case HEARTS:
-		return "Hearts"
+		return "Hearts", nil
 	case SPADES:
-		return "Spades"
+		return "Spades", nil
 	case RED_JOKER:
-		return "Red Joker"
+		return "Red Joker", nil
 	case BLACK_JOKER:
-		return "Black Joker"
+		return "Black Joker", nil
+	default:
+		return "", ErrInvalidSuit
+	}
+}
+
+func (s Suit) String() string {
+	name, err := s.Name()
+	if err != nil {
+		return "Invalid suit"
+	}
+	return name
+}
+
+// Color returns the color of the suit's cards, "Black" or "Red", or ErrInvalidSuit
+func (s Suit) Color() (string, error) {
+	switch s {
+	case CLUBS, SPADES, BLACK_JOKER:
+		return "Black", nil
+	case HEARTS, DIAMONDS, RED_JOKER:
+		return "Red", nil
 	default:
-		panic("Invalid suit value")
+		return "", ErrInvalidSuit
 	}
 }
 
@@ -62,44 +93,57 @@ func (c Card) NumberAsString() string {
 type Card struct {
 	number int
 	suit   Suit
+	deck   int
 }
 
-// SuitStringToInt returns the int equivalent of the given string
-func SuitStringToInt(suit string) Suit {
+// ParseSuit returns the suit with the given name, ignoring case, or ErrInvalidSuit
+func ParseSuit(suit string) (Suit, error) {
 	switch strings.ToUpper(suit) {
 	case "CLUBS":
-		return CLUBS
+		return CLUBS, nil
 	case "DIAMONDS":
-		return DIAMONDS
+		return DIAMONDS, nil
 	case "HEARTS":
-		return HEARTS
+		return HEARTS, nil
 	case "SPADES":
-		return SPADES
+		return SPADES, nil
 	case "RED_JOKER":
-		return RED_JOKER
+		return RED_JOKER, nil
 	case "BLACK_JOKER":
-		return BLACK_JOKER
+		return BLACK_JOKER, nil
 	default:
-		panic("A card's suit must be Clubs, Diamonds, Hearts, or Spades.")
+		return CLUBS, ErrInvalidSuit
 	}
 }
 
+// SuitStringToInt returns the int equivalent of the given string.
+// It panics if the string is not a suit name.
+//
+// Deprecated: Use ParseSuit, which returns an error instead.
+func SuitStringToInt(suit string) Suit {
+	s, err := ParseSuit(suit)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
 // NewCard creates a new playing card
 func NewCard(num int, s Suit) Card {
 	c := Card{number: num, suit: s}
 	return c
 }
 
-// Color returns the card's color
+// Color returns the card's color, "Black" or "Red".
+// It panics if the card's suit is invalid.
+//
+// Deprecated: Use Suit().Color(), which returns an error instead.
 func (c Card) Color() string {
-	switch c.Suit() {
-	case CLUBS, SPADES, BLACK_JOKER:
-		return "Black"
-	case HEARTS, DIAMONDS, RED_JOKER:
-		return "Red"
-	default:
-		panic("Invalid suit value")
+	color, err := c.suit.Color()
+	if err != nil {
+		panic(err)
 	}
+	return color
 }
 
 // Suit returns the card's suit
@@ -112,6 +156,16 @@ func (c Card) Value() int {
 	return c.number
 }
 
+// DeckIndex returns which physical deck of a shoe the card came from, starting at 0
+func (c Card) DeckIndex() int {
+	return c.deck
+}
+
+// SameFace returns whether two cards have the same value and suit, regardless of which deck of a shoe they came from
+func (c Card) SameFace(other Card) bool {
+	return c.number == other.number && c.suit == other.suit
+}
+
 func (c Card) String() string {
 	if c.suit == RED_JOKER {
 		return "Red Joker"
@@ -121,3 +175,27 @@ func (c Card) String() string {
 	}
 	return fmt.Sprintf("%s of %s", c.NumberAsString(), c.Suit())
 }
+
+// cardJSON is how a card is stored as JSON
+type cardJSON struct {
+	Value int  `json:"value"`
+	Suit  Suit `json:"suit"`
+	Deck  int  `json:"deck,omitempty"`
+}
+
+// MarshalJSON stores the card's value, suit and deck index
+func (c Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cardJSON{Value: c.number, Suit: c.suit, Deck: c.deck})
+}
+
+// UnmarshalJSON restores a card stored by MarshalJSON
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var stored cardJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	c.number = stored.Value
+	c.suit = stored.Suit
+	c.deck = stored.Deck
+	return nil
+}
@@ -1,48 +1,57 @@
 package playingcards
 
 import (
-	"math/rand"
+	"encoding/json"
+	"errors"
 )
 
-// EmptyCard is a fake card to return on invalid function calls that return a playing card
+// EmptyCard is a fake card to return on invalid function calls that return a playing card.
+// It is not a valid card, so check the error or boolean returned alongside it instead of comparing against it.
 var EmptyCard Card = NewCard(-1, CLUBS)
 
-// Deck is a standard 52-card list of playing cards
+// Errors returned when drawing and moving cards
+var (
+	ErrEmptyDeck       = errors.New("no more cards left in the deck")
+	ErrNotEnoughCards  = errors.New("not enough cards left in the deck")
+	ErrInvalidCount    = errors.New("the number of cards to draw must be at least 1")
+	ErrInvalidPosition = errors.New("the position is outside the deck")
+)
+
+// Deck is a standard 52-card list of playing cards, or a shoe made of several such decks
 type Deck struct {
-	cards []Card
+	cards    []Card
+	numDecks int
+	// cutCard is how many cards are left in the deck when the cut card is reached, 0 if there is no cut card
+	cutCard int
+	// shuffler shuffles the deck, or nil to use the default one
+	shuffler Shuffler
+	// drawn lists every card drawn from the deck since it was made, in the order they were drawn
+	drawn []Card
 }
 
-// NewDeckWithoutJokers creates a new deck of cards with no Joker cards
-func NewDeckWithoutJokers() Deck {
-	deck := make([]Card, 52)
-	i := 0
+// newCardSet creates the cards of a single ordered deck, tagged with the given deck index
+func newCardSet(deckIndex int, includeJokers bool) []Card {
+	cards := make([]Card, 0, 54)
 	for suit := CLUBS; suit <= SPADES; suit++ {
 		for n := 1; n <= 13; n++ {
-			card := NewCard(n, suit)
-			deck[i] = card
-			i++
+			cards = append(cards, Card{number: n, suit: suit, deck: deckIndex})
 		}
 	}
-	return Deck{cards: deck}
+	if includeJokers {
+		cards = append(cards, Card{number: -1, suit: RED_JOKER, deck: deckIndex})
+		cards = append(cards, Card{number: -1, suit: BLACK_JOKER, deck: deckIndex})
+	}
+	return cards
+}
+
+// NewDeckWithoutJokers creates a new deck of cards with no Joker cards
+func NewDeckWithoutJokers() Deck {
+	return NewShoe(1, false)
 }
 
 // NewDeckWithJokers creates a new deck of cards with a red and black Joker included
 func NewDeckWithJokers() Deck {
-	deck := make([]Card, 54)
-	i := 0
-	for suit := CLUBS; suit <= SPADES; suit++ {
-		for n := 1; n <= 13; n++ {
-			card := NewCard(n, suit)
-			deck[i] = card
-			i++
-		}
-	}
-	redJoker := NewCard(-1, RED_JOKER)
-	deck[i] = redJoker
-	i++
-	blackJoker := NewCard(-1, BLACK_JOKER)
-	deck[i] = blackJoker
-	return Deck{cards: deck}
+	return NewShoe(1, true)
 }
 
 // NewDeck creates a new deck of cards
@@ -54,25 +63,212 @@ func NewDeck(includeJokers bool) Deck {
 	}
 }
 
+// NewShoe creates a shoe of cards made by stacking numDecks ordered decks on top of each other.
+// Each card remembers which deck it came from, see Card.DeckIndex.
+func NewShoe(numDecks int, includeJokers bool) Deck {
+	if numDecks < 1 {
+		numDecks = 1
+	}
+	cards := make([]Card, 0, numDecks*54)
+	for i := 0; i < numDecks; i++ {
+		cards = append(cards, newCardSet(i, includeJokers)...)
+	}
+	return Deck{cards: cards, numDecks: numDecks}
+}
+
 // Size returns the number of cards remaining in this deck
 func (d Deck) Size() int {
 	return len(d.cards)
 }
 
-// DrawCard removes the top card from the deck and returns it
+// Cards returns a copy of the remaining cards in the deck, the top card first
+func (d Deck) Cards() []Card {
+	cards := make([]Card, len(d.cards))
+	for i := range cards {
+		cards[i] = d.cards[len(d.cards)-1-i]
+	}
+	return cards
+}
+
+// Drawn returns a copy of every card drawn from the deck since it was made, in the order they were drawn.
+// Cards put back into the deck stay in the list.
+func (d Deck) Drawn() []Card {
+	drawn := make([]Card, len(d.drawn))
+	copy(drawn, d.drawn)
+	return drawn
+}
+
+// NumDecks returns how many decks this deck was built from
+func (d Deck) NumDecks() int {
+	if d.numDecks < 1 {
+		return 1
+	}
+	return d.numDecks
+}
+
+// SetPenetration places the cut card so that the given fraction (between 0 and 1) of the remaining cards
+// can be drawn before the deck should be reshuffled. A penetration of 0 or less removes the cut card.
+// A penetration of 1 or more would put the cut card past the last card, so it returns ErrInvalidPosition
+// and leaves the cut card where it was.
+func (d *Deck) SetPenetration(penetration float64) error {
+	if penetration <= 0 {
+		d.cutCard = 0
+		return nil
+	}
+	if penetration >= 1 {
+		return ErrInvalidPosition
+	}
+	d.cutCard = len(d.cards) - int(float64(len(d.cards))*penetration)
+	return nil
+}
+
+// CutCardReached returns whether enough cards have been drawn to reach the cut card, signaling a reshuffle
+func (d Deck) CutCardReached() bool {
+	return d.cutCard > 0 && len(d.cards) <= d.cutCard
+}
+
+// Draw removes the top card from the deck and returns it, or returns ErrEmptyDeck if there are no cards left
+func (d *Deck) Draw() (Card, error) {
+	if len(d.cards) == 0 {
+		d.cards = nil
+		return EmptyCard, ErrEmptyDeck
+	}
+	topCard := d.cards[len(d.cards)-1]
+	d.cards = d.cards[:len(d.cards)-1]
+	d.drawn = append(d.drawn, topCard)
+	return topCard, nil
+}
+
+// DrawCard removes the top card from the deck and returns it, or EmptyCard if the deck is empty.
+//
+// Deprecated: Use Draw, which reports an empty deck with ErrEmptyDeck.
 func (d *Deck) DrawCard() Card {
-	if len(d.cards) > 0 {
-		topCard := d.cards[len(d.cards)-1]
-		d.cards = d.cards[:len(d.cards)-1]
-		return topCard
+	card, _ := d.Draw()
+	return card
+}
+
+// DrawCards removes the top n cards from the deck and returns them, the top card first.
+// If the deck has fewer than n cards left, it is left untouched and ErrNotEnoughCards is returned.
+func (d *Deck) DrawCards(n int) ([]Card, error) {
+	if n < 1 {
+		return nil, ErrInvalidCount
+	}
+	if n > len(d.cards) {
+		return nil, ErrNotEnoughCards
+	}
+	drawn := make([]Card, n)
+	for i := range drawn {
+		drawn[i] = d.cards[len(d.cards)-1-i]
+	}
+	d.cards = d.cards[:len(d.cards)-n]
+	d.drawn = append(d.drawn, drawn...)
+	return drawn, nil
+}
+
+// SetShuffler makes Shuffle use the given Shuffler, or the default one set by SetDefaultShuffler if it is nil
+func (d *Deck) SetShuffler(s Shuffler) {
+	d.shuffler = s
+}
+
+// DrawFromBottom removes the bottom card from the deck and returns it, or returns ErrEmptyDeck if there are no cards left
+func (d *Deck) DrawFromBottom() (Card, error) {
+	if len(d.cards) == 0 {
+		return EmptyCard, ErrEmptyDeck
+	}
+	bottomCard := d.cards[0]
+	d.cards = d.cards[1:]
+	d.drawn = append(d.drawn, bottomCard)
+	return bottomCard, nil
+}
+
+// Peek returns the top n cards of the deck without drawing them, the top card first
+func (d Deck) Peek(n int) ([]Card, error) {
+	if n < 1 {
+		return nil, ErrInvalidCount
+	}
+	if n > len(d.cards) {
+		return nil, ErrNotEnoughCards
+	}
+	cards := make([]Card, n)
+	for i := range cards {
+		cards[i] = d.cards[len(d.cards)-1-i]
+	}
+	return cards, nil
+}
+
+// Cut takes the given number of cards off the top of the deck and puts them underneath the rest.
+// The position must leave cards on both sides of the cut, so between 1 and Size()-1.
+func (d *Deck) Cut(position int) error {
+	if position < 1 || position >= len(d.cards) {
+		return ErrInvalidPosition
 	}
-	d.cards = nil
-	return EmptyCard
+	split := len(d.cards) - position
+	cut := make([]Card, 0, len(d.cards))
+	cut = append(cut, d.cards[split:]...)
+	cut = append(cut, d.cards[:split]...)
+	d.cards = cut
+	return nil
+}
+
+// Insert puts a card into the deck with the given number of cards above it, so 0 puts it on top and Size() at the bottom
+func (d *Deck) Insert(c Card, position int) error {
+	if position < 0 || position > len(d.cards) {
+		return ErrInvalidPosition
+	}
+	i := len(d.cards) - position
+	d.cards = append(d.cards, EmptyCard)
+	copy(d.cards[i+1:], d.cards[i:])
+	d.cards[i] = c
+	return nil
+}
+
+// AddToBottom puts the given cards at the bottom of the deck, the first one lowest
+func (d *Deck) AddToBottom(cards ...Card) {
+	d.cards = append(append(make([]Card, 0, len(cards)+len(d.cards)), cards...), d.cards...)
 }
 
 // Shuffle randomizes the order of the remaining cards in the deck
 func (d *Deck) Shuffle() {
-	rand.Shuffle(len(d.cards), func(i, j int) {
+	if d.shuffler != nil {
+		d.ShuffleWith(d.shuffler)
+	} else {
+		d.ShuffleWith(getDefaultShuffler())
+	}
+}
+
+// ShuffleWith randomizes the order of the remaining cards in the deck with the given Shuffler
+func (d *Deck) ShuffleWith(s Shuffler) {
+	s.Shuffle(len(d.cards), func(i, j int) {
 		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
 	})
 }
+
+// deckJSON is how a deck is stored as JSON, with the top card last
+type deckJSON struct {
+	Cards    []Card `json:"cards"`
+	NumDecks int    `json:"numDecks"`
+	CutCard  int    `json:"cutCard,omitempty"`
+	Drawn    []Card `json:"drawn,omitempty"`
+}
+
+// MarshalJSON stores the remaining cards in order, along with the shoe settings
+func (d Deck) MarshalJSON() ([]byte, error) {
+	cards := d.cards
+	if cards == nil {
+		cards = []Card{}
+	}
+	return json.Marshal(deckJSON{Cards: cards, NumDecks: d.NumDecks(), CutCard: d.cutCard, Drawn: d.drawn})
+}
+
+// UnmarshalJSON restores a deck stored by MarshalJSON
+func (d *Deck) UnmarshalJSON(data []byte) error {
+	var stored deckJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	d.cards = stored.Cards
+	d.numDecks = stored.NumDecks
+	d.cutCard = stored.CutCard
+	d.drawn = stored.Drawn
+	return nil
+}
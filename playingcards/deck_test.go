@@ -0,0 +1,135 @@
+package playingcards
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewShoe(t *testing.T) {
+	tests := []struct {
+		numDecks      int
+		includeJokers bool
+		wantDecks     int
+		wantSize      int
+	}{
+		{1, false, 1, 52},
+		{1, true, 1, 54},
+		{6, false, 6, 312},
+		{8, true, 8, 432},
+		{0, false, 1, 52},
+		{-2, false, 1, 52},
+	}
+	for _, test := range tests {
+		shoe := NewShoe(test.numDecks, test.includeJokers)
+		if shoe.Size() != test.wantSize || shoe.NumDecks() != test.wantDecks {
+			t.Errorf("NewShoe(%d, %v) has %d cards from %d decks, want %d cards from %d decks",
+				test.numDecks, test.includeJokers, shoe.Size(), shoe.NumDecks(), test.wantSize, test.wantDecks)
+		}
+	}
+}
+
+func TestShoeDeckIndex(t *testing.T) {
+	shoe := NewShoe(3, true)
+	perDeck := make(map[int]int)
+	faces := make(map[string]int)
+	for _, card := range shoe.Cards() {
+		perDeck[card.DeckIndex()]++
+		faces[card.Short()]++
+	}
+	for deck := 0; deck < 3; deck++ {
+		if perDeck[deck] != 54 {
+			t.Errorf("deck %d of the shoe has %d cards, want 54", deck, perDeck[deck])
+		}
+	}
+	if len(perDeck) != 3 {
+		t.Errorf("the shoe's cards come from %d decks, want 3", len(perDeck))
+	}
+	for face, count := range faces {
+		if count != 3 {
+			t.Errorf("the shoe has %d copies of %s, want one from each deck", count, face)
+		}
+	}
+	// The decks are stacked, so the top card comes from the last deck
+	if top, _ := shoe.Peek(1); top[0].DeckIndex() != 2 {
+		t.Errorf("the top card comes from deck %d, want deck 2", top[0].DeckIndex())
+	}
+	// Cards of different decks are still the same face
+	cards := shoe.Cards()
+	if !cards[0].SameFace(cards[54]) || cards[0] == cards[54] {
+		t.Error("the same card from two decks should have the same face but be different cards")
+	}
+}
+
+func TestSetPenetration(t *testing.T) {
+	tests := []struct {
+		penetration float64
+		wantErr     error
+		// wantDraws is how many cards can be drawn from a 100 card deck before the cut card is reached, or -1 if never
+		wantDraws int
+	}{
+		{0.75, nil, 75},
+		{0.5, nil, 50},
+		{0.01, nil, 1},
+		{0, nil, -1},
+		{-0.5, nil, -1},
+		{1, ErrInvalidPosition, -1},
+		{1.5, ErrInvalidPosition, -1},
+	}
+	for _, test := range tests {
+		deck := NewShoe(2, false)
+		deck.DrawCards(4)
+		if err := deck.SetPenetration(test.penetration); err != test.wantErr {
+			t.Errorf("SetPenetration(%v) returned %v, want %v", test.penetration, err, test.wantErr)
+		}
+		draws := -1
+		for n := 0; deck.Size() > 0; n++ {
+			if deck.CutCardReached() {
+				draws = n
+				break
+			}
+			deck.Draw()
+		}
+		if draws != test.wantDraws {
+			t.Errorf("with a penetration of %v, the cut card was reached after %d draws, want %d", test.penetration, draws, test.wantDraws)
+		}
+	}
+}
+
+func TestSetPenetrationKeepsCutCardOnError(t *testing.T) {
+	deck := NewDeck(false)
+	deck.SetPenetration(0.5)
+	if err := deck.SetPenetration(1); err != ErrInvalidPosition {
+		t.Fatalf("SetPenetration(1) returned %v, want ErrInvalidPosition", err)
+	}
+	deck.DrawCards(26)
+	if !deck.CutCardReached() {
+		t.Error("a rejected penetration removed the cut card that was already placed")
+	}
+}
+
+func TestShoeJSON(t *testing.T) {
+	shoe := NewShoe(4, false)
+	shoe.SetPenetration(0.75)
+	shoe.DrawCards(10)
+	data, err := json.Marshal(shoe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Deck
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.NumDecks() != 4 || restored.Size() != shoe.Size() || len(restored.Drawn()) != 10 {
+		t.Errorf("restored a shoe of %d decks with %d cards and %d drawn, want 4 decks, %d cards and 10 drawn",
+			restored.NumDecks(), restored.Size(), len(restored.Drawn()), shoe.Size())
+	}
+	restored.DrawCards(restored.Size() - 52)
+	if !restored.CutCardReached() {
+		t.Error("the restored shoe lost its cut card")
+	}
+	for n, card := range restored.Cards() {
+		if card != shoe.Cards()[len(shoe.Cards())-len(restored.Cards())+n] {
+			t.Fatalf("card %d of the restored shoe is %s from deck %d", n, card.Short(), card.DeckIndex())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package playingcards
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Provably fair shuffling works by commit-reveal: the server picks a secret seed and publishes its hash
+// before the game, players contribute a client seed, and the deck is shuffled from both seeds.
+// Once the game is over the server seed is revealed, so anyone can check that it matches the published hash
+// and repeat the shuffle to get the same order of cards.
+//
+// The shuffle is a Fisher-Yates shuffle, going from the last card down to the second, that swaps each card
+// with a card at a random position at or below it. The random numbers come from HMAC-SHA256 keyed with the
+// server seed, of the message "<client seed>:<counter>" with the counter starting at 0. Each 32 byte result is
+// read as eight big-endian uint32 values, and a position below n is the value modulo n, skipping values
+// at or above the largest multiple of n so every position is equally likely.
+
+// NewServerSeed returns a new random secret seed for a provably fair shuffle, as 64 hex digits
+func NewServerSeed() (string, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// HashSeed returns the SHA-256 hash of a server seed as hex digits, which is published before the seed is used
+func HashSeed(serverSeed string) string {
+	hash := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(hash[:])
+}
+
+// VerifySeed returns whether a revealed server seed matches the hash published before the game
+func VerifySeed(serverSeed string, hash string) bool {
+	return hmac.Equal([]byte(HashSeed(serverSeed)), []byte(strings.ToLower(strings.TrimSpace(hash))))
+}
+
+// ShuffleWithSeeds shuffles the remaining cards in the deck into an order fully determined by the two seeds,
+// so the shuffle can be repeated to verify it
+func (d *Deck) ShuffleWithSeeds(serverSeed string, clientSeed string) {
+	d.ShuffleWith(NewFairShuffler(serverSeed, clientSeed))
+}
+
+// NewFairShuffler returns a Shuffler for a provably fair shuffle with the given seeds.
+// It starts over from the seeds for every shuffle, so it always gives the same order for the same number of items.
+func NewFairShuffler(serverSeed string, clientSeed string) Shuffler {
+	return fairShuffler{serverSeed: serverSeed, clientSeed: clientSeed}
+}
+
+// fairShuffler is the Shuffler of a provably fair shuffle
+type fairShuffler struct {
+	serverSeed string
+	clientSeed string
+}
+
+func (f fairShuffler) Shuffle(n int, swap func(i, j int)) {
+	stream := seedStream{key: []byte(f.serverSeed), clientSeed: f.clientSeed}
+	for i := n - 1; i > 0; i-- {
+		swap(i, stream.intn(i+1))
+	}
+}
+
+// seedStream generates the random numbers of a provably fair shuffle
+type seedStream struct {
+	key        []byte
+	clientSeed string
+	counter    int
+	block      []byte
+}
+
+// uint32 returns the next random number of the stream
+func (s *seedStream) uint32() uint32 {
+	if len(s.block) == 0 {
+		h := hmac.New(sha256.New, s.key)
+		fmt.Fprintf(h, "%s:%d", s.clientSeed, s.counter)
+		s.block = h.Sum(nil)
+		s.counter++
+	}
+	value := binary.BigEndian.Uint32(s.block)
+	s.block = s.block[4:]
+	return value
+}
+
+// intn returns an evenly distributed random number in [0, n)
+func (s *seedStream) intn(n int) int {
+	bound := uint32(n)
+	limit := ^uint32(0) - (^uint32(0)%bound+1)%bound
+	for {
+		value := s.uint32()
+		if value <= limit {
+			return int(value % bound)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package playingcards
+
+import (
+	"sort"
+	"strings"
+)
+
+// RankOrder decides how cards are ranked against each other when sorting and comparing
+type RankOrder struct {
+	aceHigh  bool
+	trump    Suit
+	hasTrump bool
+}
+
+// AceLow ranks aces below twos, the same as Card.Value
+var AceLow = RankOrder{}
+
+// AceHigh ranks aces above kings
+var AceHigh = RankOrder{aceHigh: true}
+
+// jokerRank puts Joker cards above every other card
+const jokerRank = 15
+
+// WithTrump returns a copy of the rank order where cards of the given suit beat cards of any other suit
+func (o RankOrder) WithTrump(s Suit) RankOrder {
+	o.trump = s
+	o.hasTrump = true
+	return o
+}
+
+// WithoutTrump returns a copy of the rank order with no trump suit
+func (o RankOrder) WithoutTrump() RankOrder {
+	o.hasTrump = false
+	return o
+}
+
+// AceIsHigh returns whether aces rank above kings in this order
+func (o RankOrder) AceIsHigh() bool {
+	return o.aceHigh
+}
+
+// Trump returns the trump suit, and whether the order has one
+func (o RankOrder) Trump() (Suit, bool) {
+	return o.trump, o.hasTrump
+}
+
+// Rank returns the rank of the card within its suit, ignoring trumps
+func (o RankOrder) Rank(c Card) int {
+	if c.suit == RED_JOKER || c.suit == BLACK_JOKER {
+		return jokerRank
+	}
+	if c.number == 1 && o.aceHigh {
+		return 14
+	}
+	return c.number
+}
+
+// IsTrump returns whether the card belongs to the trump suit
+func (o RankOrder) IsTrump(c Card) bool {
+	return o.hasTrump && c.suit == o.trump
+}
+
+// Compare returns a negative number if a ranks below b, a positive number if a ranks above b, or 0 if they rank the same.
+// Trump cards beat all other cards, otherwise only the ranks are compared.
+func (o RankOrder) Compare(a, b Card) int {
+	aTrump, bTrump := o.IsTrump(a), o.IsTrump(b)
+	if aTrump && !bTrump {
+		return 1
+	}
+	if bTrump && !aTrump {
+		return -1
+	}
+	return o.Rank(a) - o.Rank(b)
+}
+
+// suitIndex orders the suits for sorting, moving the trump suit last so it ends up at the high end of a hand
+func (o RankOrder) suitIndex(s Suit) int {
+	if o.hasTrump && s == o.trump {
+		return int(BLACK_JOKER) + 1
+	}
+	return int(s)
+}
+
+// Hand is a player's collection of playing cards
+type Hand struct {
+	cards []Card
+}
+
+// NewHand creates a hand holding the given cards
+func NewHand(cards ...Card) Hand {
+	h := Hand{}
+	h.Add(cards...)
+	return h
+}
+
+// Size returns the number of cards in the hand
+func (h Hand) Size() int {
+	return len(h.cards)
+}
+
+// Cards returns a copy of the cards in the hand
+func (h Hand) Cards() []Card {
+	cards := make([]Card, len(h.cards))
+	copy(cards, h.cards)
+	return cards
+}
+
+// Card returns the card at the given position in the hand
+func (h Hand) Card(i int) Card {
+	return h.cards[i]
+}
+
+// Add puts the given cards at the end of the hand
+func (h *Hand) Add(cards ...Card) {
+	h.cards = append(h.cards, cards...)
+}
+
+// IndexOf returns the position of the first card with the same face as the given card, or -1 if the hand doesn't have it
+func (h Hand) IndexOf(c Card) int {
+	for i, card := range h.cards {
+		if card.SameFace(c) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains returns whether the hand has a card with the same face as the given card
+func (h Hand) Contains(c Card) bool {
+	return h.IndexOf(c) >= 0
+}
+
+// Remove takes the first card with the same face as the given card out of the hand, returning the removed card
+func (h *Hand) Remove(c Card) (Card, bool) {
+	i := h.IndexOf(c)
+	if i < 0 {
+		return EmptyCard, false
+	}
+	return h.RemoveAt(i)
+}
+
+// RemoveAt takes the card at the given position out of the hand. The remaining cards are copied to a new slice,
+// so copies of the hand made before the call keep their cards.
+func (h *Hand) RemoveAt(i int) (Card, bool) {
+	if i < 0 || i >= len(h.cards) {
+		return EmptyCard, false
+	}
+	card := h.cards[i]
+	cards := make([]Card, 0, len(h.cards)-1)
+	cards = append(cards, h.cards[:i]...)
+	h.cards = append(cards, h.cards[i+1:]...)
+	return card, true
+}
+
+// Clear removes every card from the hand and returns them
+func (h *Hand) Clear() []Card {
+	cards := h.cards
+	h.cards = nil
+	return cards
+}
+
+// SortByRank orders the hand from lowest to highest rank, breaking ties by suit
+func (h *Hand) SortByRank(o RankOrder) {
+	sort.SliceStable(h.cards, func(i, j int) bool {
+		a, b := h.cards[i], h.cards[j]
+		if cmp := o.Compare(a, b); cmp != 0 {
+			return cmp < 0
+		}
+		return o.suitIndex(a.suit) < o.suitIndex(b.suit)
+	})
+}
+
+// SortBySuit groups the hand by suit (trumps last), ordering each suit from lowest to highest rank
+func (h *Hand) SortBySuit(o RankOrder) {
+	sort.SliceStable(h.cards, func(i, j int) bool {
+		a, b := h.cards[i], h.cards[j]
+		if a.suit != b.suit {
+			return o.suitIndex(a.suit) < o.suitIndex(b.suit)
+		}
+		return o.Rank(a) < o.Rank(b)
+	})
+}
+
+// GroupBySuit returns the cards in the hand keyed by their suit
+func (h Hand) GroupBySuit() map[Suit][]Card {
+	groups := make(map[Suit][]Card)
+	for _, card := range h.cards {
+		groups[card.suit] = append(groups[card.suit], card)
+	}
+	return groups
+}
+
+// GroupByRank returns the cards in the hand keyed by their rank in the given order
+func (h Hand) GroupByRank(o RankOrder) map[int][]Card {
+	groups := make(map[int][]Card)
+	for _, card := range h.cards {
+		rank := o.Rank(card)
+		groups[rank] = append(groups[rank], card)
+	}
+	return groups
+}
+
+// Highest returns the highest ranking card in the hand, or false if the hand is empty
+func (h Hand) Highest(o RankOrder) (Card, bool) {
+	if len(h.cards) == 0 {
+		return EmptyCard, false
+	}
+	best := h.cards[0]
+	for _, card := range h.cards[1:] {
+		if o.Compare(card, best) > 0 {
+			best = card
+		}
+	}
+	return best, true
+}
+
+func (h Hand) String() string {
+	names := make([]string, len(h.cards))
+	for i, card := range h.cards {
+		names[i] = card.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,93 @@
+package playingcards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandRemove(t *testing.T) {
+	hand := NewHand(parseCards(t, "AS", "KH", "QD", "KH")...)
+	copied := hand
+	card, ok := hand.RemoveAt(1)
+	if !ok || card.Short() != "KH" {
+		t.Fatalf("RemoveAt(1) = %s, %v, want KH", card.Short(), ok)
+	}
+	if got := shorts(hand.Cards()); !reflect.DeepEqual(got, []string{"AS", "QD", "KH"}) {
+		t.Errorf("after RemoveAt(1) the hand is %v, want [AS QD KH]", got)
+	}
+	// A copy of the hand made before shares nothing with it afterwards
+	if got := shorts(copied.Cards()); !reflect.DeepEqual(got, []string{"AS", "KH", "QD", "KH"}) {
+		t.Errorf("RemoveAt changed a copy of the hand to %v", got)
+	}
+	for _, i := range []int{-1, 3} {
+		if _, ok := hand.RemoveAt(i); ok {
+			t.Errorf("RemoveAt(%d) succeeded on a hand of 3 cards", i)
+		}
+	}
+
+	if _, ok := hand.Remove(parseCards(t, "KH")[0]); !ok || hand.Contains(parseCards(t, "KH")[0]) {
+		t.Error("Remove(KH) didn't take the card out of the hand")
+	}
+	if _, ok := hand.Remove(parseCards(t, "2C")[0]); ok {
+		t.Error("Remove(2C) found a card the hand doesn't have")
+	}
+}
+
+func TestHandSort(t *testing.T) {
+	cards := []string{"KH", "AS", "2C", "RJ", "10H", "AD", "2H"}
+	tests := []struct {
+		name string
+		sort func(h *Hand)
+		want []string
+	}{
+		{"by rank, aces low", func(h *Hand) { h.SortByRank(AceLow) }, []string{"AD", "AS", "2C", "2H", "10H", "KH", "RJ"}},
+		{"by rank, aces high", func(h *Hand) { h.SortByRank(AceHigh) }, []string{"2C", "2H", "10H", "KH", "AD", "AS", "RJ"}},
+		{"by rank with hearts trump", func(h *Hand) { h.SortByRank(AceHigh.WithTrump(HEARTS)) }, []string{"2C", "AD", "AS", "RJ", "2H", "10H", "KH"}},
+		{"by suit, aces low", func(h *Hand) { h.SortBySuit(AceLow) }, []string{"2C", "AD", "2H", "10H", "KH", "AS", "RJ"}},
+		{"by suit, aces high", func(h *Hand) { h.SortBySuit(AceHigh) }, []string{"2C", "AD", "2H", "10H", "KH", "AS", "RJ"}},
+		{"by suit with clubs trump", func(h *Hand) { h.SortBySuit(AceHigh.WithTrump(CLUBS)) }, []string{"AD", "2H", "10H", "KH", "AS", "RJ", "2C"}},
+	}
+	for _, test := range tests {
+		hand := NewHand(parseCards(t, cards...)...)
+		test.sort(&hand)
+		if got := shorts(hand.Cards()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sorting %s gave %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRankOrder(t *testing.T) {
+	ace, king, two := parseCards(t, "AS")[0], parseCards(t, "KS")[0], parseCards(t, "2H")[0]
+	if AceLow.Compare(ace, two) >= 0 || AceHigh.Compare(ace, king) <= 0 {
+		t.Error("aces should rank below twos when low and above kings when high")
+	}
+	hearts := AceHigh.WithTrump(HEARTS)
+	if hearts.Compare(two, ace) <= 0 || !hearts.IsTrump(two) {
+		t.Error("a trump two should beat an ace of another suit")
+	}
+	if trump, ok := hearts.WithoutTrump().Trump(); ok {
+		t.Errorf("WithoutTrump still has %s as trump", trump.String())
+	}
+	if best, _ := NewHand(ace, king, two).Highest(hearts); best.Short() != "2H" {
+		t.Errorf("the highest card with hearts trump is %s, want 2H", best.Short())
+	}
+	if _, ok := NewHand().Highest(AceHigh); ok {
+		t.Error("an empty hand has a highest card")
+	}
+}
+
+func TestHandGroups(t *testing.T) {
+	hand := NewHand(parseCards(t, "AS", "KH", "AD", "5S", "KD")...)
+	suits := hand.GroupBySuit()
+	if len(suits) != 3 || len(suits[SPADES]) != 2 || len(suits[DIAMONDS]) != 2 || len(suits[HEARTS]) != 1 {
+		t.Errorf("GroupBySuit = %v, want 2 spades, 2 diamonds and 1 heart", suits)
+	}
+	low := hand.GroupByRank(AceLow)
+	if got := shorts(low[1]); !reflect.DeepEqual(got, []string{"AS", "AD"}) {
+		t.Errorf("GroupByRank(AceLow)[1] = %v, want the aces in hand order", got)
+	}
+	high := hand.GroupByRank(AceHigh)
+	if len(high[14]) != 2 || len(high[1]) != 0 || len(high[13]) != 2 || len(high[5]) != 1 {
+		t.Errorf("GroupByRank(AceHigh) = %v, want the aces under 14", high)
+	}
+}
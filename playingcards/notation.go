@@ -0,0 +1,208 @@
+package playingcards
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCard is returned when a card can't be parsed
+var ErrInvalidCard = errors.New("invalid card")
+
+// Short notation of the card values and suits, e.g. "AS" for the Ace of Spades and "10H" for the 10 of Hearts
+var (
+	shortValues = []string{"", "A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+	shortSuits  = map[Suit]string{
+		CLUBS:    "C",
+		DIAMONDS: "D",
+		HEARTS:   "H",
+		SPADES:   "S",
+	}
+	// suitSymbols are also accepted in place of the suit letters, e.g. "A♠"
+	suitSymbols = map[string]Suit{
+		"♣": CLUBS, "♧": CLUBS,
+		"♦": DIAMONDS, "♢": DIAMONDS,
+		"♥": HEARTS, "♡": HEARTS,
+		"♠": SPADES, "♤": SPADES,
+	}
+)
+
+// Unicode playing card glyphs. Each suit has its own row starting at the Ace, and the Knight between
+// the Jack and the Queen is skipped, e.g. 🂡 is the Ace of Spades and 🂮 is the King of Spades.
+const (
+	glyphBack       = '\U0001F0A0'
+	glyphRedJoker   = '\U0001F0BF'
+	glyphBlackJoker = '\U0001F0CF'
+)
+
+var glyphSuitRows = map[Suit]rune{
+	SPADES:   '\U0001F0A0',
+	HEARTS:   '\U0001F0B0',
+	DIAMONDS: '\U0001F0C0',
+	CLUBS:    '\U0001F0D0',
+}
+
+// valid returns whether the card is a real card, rather than EmptyCard or a card with a bad value or suit
+func (c Card) valid() bool {
+	if c.suit == RED_JOKER || c.suit == BLACK_JOKER {
+		return true
+	}
+	return c.suit.Valid() && c.number >= 1 && c.number <= 13
+}
+
+// Short returns the card in short notation: the value ("A", "2" to "10", "J", "Q", "K") followed by the suit's
+// initial, e.g. "QH" for the Queen of Hearts. Jokers are "RJ" and "BJ", and invalid cards are "?".
+func (c Card) Short() string {
+	switch {
+	case !c.valid():
+		return "?"
+	case c.suit == RED_JOKER:
+		return "RJ"
+	case c.suit == BLACK_JOKER:
+		return "BJ"
+	}
+	return shortValues[c.number] + shortSuits[c.suit]
+}
+
+// Glyph returns the Unicode playing card character for the card, e.g. "🂡" for the Ace of Spades.
+// Invalid cards are shown face down as "🂠".
+func (c Card) Glyph() string {
+	switch {
+	case !c.valid():
+		return string(glyphBack)
+	case c.suit == RED_JOKER:
+		return string(glyphRedJoker)
+	case c.suit == BLACK_JOKER:
+		return string(glyphBlackJoker)
+	}
+	offset := rune(c.number)
+	if c.number >= 12 {
+		offset++ // Skip the Knight
+	}
+	return string(glyphSuitRows[c.suit] + offset)
+}
+
+// ParseCard reads a card written in short notation ("AS", "10h", "Td", "qc", "A♠", "RJ"),
+// as a long name like the ones Card.String returns ("Queen of Hearts", "Red Joker"), or as a Unicode glyph ("🂡").
+// Case and surrounding spaces are ignored. It returns an error wrapping ErrInvalidCard if the text isn't a card.
+func ParseCard(s string) (Card, error) {
+	text := strings.TrimSpace(s)
+	if card, ok := parseGlyph(text); ok {
+		return card, nil
+	}
+	upper := strings.ToUpper(text)
+	switch upper {
+	case "RJ", "RED JOKER":
+		return NewCard(-1, RED_JOKER), nil
+	case "BJ", "BLACK JOKER":
+		return NewCard(-1, BLACK_JOKER), nil
+	}
+	if parts := strings.SplitN(upper, " OF ", 2); len(parts) == 2 {
+		if card, ok := parseLong(parts[0], parts[1]); ok {
+			return card, nil
+		}
+	} else if card, ok := parseShort(upper); ok {
+		return card, nil
+	}
+	return EmptyCard, fmt.Errorf("%w: %q", ErrInvalidCard, s)
+}
+
+// ParseCards reads a list of cards separated by commas or spaces, e.g. "AS KH 10d" or "Ace of Spades, King of Hearts"
+func ParseCards(s string) ([]Card, error) {
+	cards := []Card{}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		if card, err := ParseCard(part); err == nil {
+			cards = append(cards, card)
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			card, err := ParseCard(field)
+			if err != nil {
+				return nil, err
+			}
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+// parseGlyph reads a card written as a single Unicode playing card glyph
+func parseGlyph(text string) (Card, bool) {
+	runes := []rune(text)
+	if len(runes) != 1 {
+		return EmptyCard, false
+	}
+	r := runes[0]
+	switch r {
+	case glyphRedJoker:
+		return NewCard(-1, RED_JOKER), true
+	case glyphBlackJoker:
+		return NewCard(-1, BLACK_JOKER), true
+	}
+	for suit, row := range glyphSuitRows {
+		offset := int(r - row)
+		if offset < 1 || offset > 14 || offset == 12 {
+			continue
+		}
+		if offset > 12 {
+			offset-- // Skip the Knight
+		}
+		return NewCard(offset, suit), true
+	}
+	return EmptyCard, false
+}
+
+// parseShort reads an upper case card in short notation
+func parseShort(text string) (Card, bool) {
+	for symbol, suit := range suitSymbols {
+		if strings.HasSuffix(text, symbol) {
+			value, ok := parseShortValue(strings.TrimSuffix(text, symbol))
+			return NewCard(value, suit), ok
+		}
+	}
+	if len(text) < 2 {
+		return EmptyCard, false
+	}
+	valueText, suitText := text[:len(text)-1], text[len(text)-1:]
+	for suit, initial := range shortSuits {
+		if initial == suitText {
+			value, ok := parseShortValue(valueText)
+			return NewCard(value, suit), ok
+		}
+	}
+	return EmptyCard, false
+}
+
+// parseShortValue reads an upper case card value in short notation, where "T" is also accepted for 10
+func parseShortValue(text string) (int, bool) {
+	if text == "T" {
+		return 10, true
+	}
+	for value := 1; value < len(shortValues); value++ {
+		if shortValues[value] == text {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// parseLong reads the upper case value and suit of a long card name, e.g. "QUEEN" and "HEARTS"
+func parseLong(valueText string, suitText string) (Card, bool) {
+	suit, err := ParseSuit(strings.TrimSpace(suitText))
+	if err != nil || suit == RED_JOKER || suit == BLACK_JOKER {
+		return EmptyCard, false
+	}
+	valueText = strings.TrimSpace(valueText)
+	for value := 1; value <= 13; value++ {
+		if strings.ToUpper(NewCard(value, suit).NumberAsString()) == valueText {
+			return NewCard(value, suit), true
+		}
+	}
+	if value, ok := parseShortValue(valueText); ok {
+		return NewCard(value, suit), true
+	}
+	return EmptyCard, false
+}
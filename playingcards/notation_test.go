@@ -0,0 +1,98 @@
+package playingcards
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestNotationRoundTrip writes every card of a deck with Jokers in each notation and reads it back
+func TestNotationRoundTrip(t *testing.T) {
+	notations := map[string]func(Card) string{
+		"short":       Card.Short,
+		"short lower": func(c Card) string { return strings.ToLower(c.Short()) },
+		"glyph":       Card.Glyph,
+		"long":        Card.String,
+		"long upper":  func(c Card) string { return " " + strings.ToUpper(c.String()) + " " },
+	}
+	cards := newCardSet(0, true)
+	if len(cards) != 54 {
+		t.Fatalf("the deck has %d cards, want 54", len(cards))
+	}
+	for name, write := range notations {
+		seen := make(map[string]bool)
+		for _, card := range cards {
+			text := write(card)
+			if seen[text] {
+				t.Errorf("%s: %q is written the same as another card", name, text)
+			}
+			seen[text] = true
+			parsed, err := ParseCard(text)
+			if err != nil {
+				t.Errorf("%s: ParseCard(%q): %v", name, text, err)
+				continue
+			}
+			if !parsed.SameFace(card) {
+				t.Errorf("%s: ParseCard(%q) = %v, want %v", name, text, parsed, card)
+			}
+		}
+	}
+}
+
+func TestGlyphSkipsKnight(t *testing.T) {
+	tests := map[string]string{
+		"JS": "🂫",
+		"QS": "🂭",
+		"KS": "🂮",
+		"AH": "🂱",
+		"QD": "🃍",
+		"KC": "🃞",
+		"RJ": "🂿",
+		"BJ": "🃏",
+	}
+	for code, glyph := range tests {
+		card, _ := ParseCard(code)
+		if card.Glyph() != glyph {
+			t.Errorf("%s.Glyph() = %s, want %s", code, card.Glyph(), glyph)
+		}
+	}
+	if EmptyCard.Glyph() != "🂠" {
+		t.Errorf("an invalid card's glyph is %s, want the card back 🂠", EmptyCard.Glyph())
+	}
+}
+
+func TestParseCardRejectsMalformed(t *testing.T) {
+	for _, text := range []string{
+		"", " ", "A", "S", "1S", "11H", "0D", "ZS", "AX", "10", "JJ", "AS KH", "ASS",
+		"Knight of Spades", "Ace of Jokers", "Ace of", "of Spades", "Eleven of Hearts",
+		"🂬", // The Knight of Spades
+		"🂠", // The back of a card
+		"🂡🂡",
+	} {
+		card, err := ParseCard(text)
+		if err == nil {
+			t.Errorf("ParseCard(%q) = %v, want an error", text, card)
+		} else if !errors.Is(err, ErrInvalidCard) {
+			t.Errorf("ParseCard(%q) returned %v, which doesn't wrap ErrInvalidCard", text, err)
+		}
+	}
+}
+
+func TestParseCards(t *testing.T) {
+	cards, err := ParseCards("AS kh 10d, Queen of Hearts,🂡 RJ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"AS", "KH", "10D", "QH", "AS", "RJ"}
+	if len(cards) != len(want) {
+		t.Fatalf("got %d cards, want %d", len(cards), len(want))
+	}
+	for i, card := range cards {
+		if card.Short() != want[i] {
+			t.Errorf("card %d is %s, want %s", i, card.Short(), want[i])
+		}
+	}
+	if _, err := ParseCards("AS XX"); err == nil {
+		t.Error("ParseCards accepted a bad card")
+	}
+}
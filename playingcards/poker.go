@@ -0,0 +1,378 @@
+package playingcards
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// PokerCategory is the kind of poker hand, from a high card up to five of a kind
+type PokerCategory int
+
+// Constants for poker hand categories, from weakest to strongest
+const (
+	HighCard PokerCategory = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+	RoyalFlush
+	// FiveOfAKind is only possible when Joker cards are wild
+	FiveOfAKind
+)
+
+func (c PokerCategory) String() string {
+	switch c {
+	case HighCard:
+		return "High Card"
+	case OnePair:
+		return "Pair"
+	case TwoPair:
+		return "Two Pair"
+	case ThreeOfAKind:
+		return "Three of a Kind"
+	case Straight:
+		return "Straight"
+	case Flush:
+		return "Flush"
+	case FullHouse:
+		return "Full House"
+	case FourOfAKind:
+		return "Four of a Kind"
+	case StraightFlush:
+		return "Straight Flush"
+	case RoyalFlush:
+		return "Royal Flush"
+	case FiveOfAKind:
+		return "Five of a Kind"
+	default:
+		return "Invalid poker hand"
+	}
+}
+
+// ErrPokerHandSize is returned when trying to evaluate fewer than 5 or more than 7 cards
+var ErrPokerHandSize = errors.New("a poker hand must have between 5 and 7 cards")
+
+// PokerRank is the strength of a poker hand. A higher PokerRank always beats a lower one, and equal ranks split the pot.
+// The category is stored in the top bits, followed by up to five 4-bit card ranks (2 to 14, ace high) used to break ties.
+type PokerRank uint32
+
+const pokerCategoryShift = 20
+
+func newPokerRank(category PokerCategory, ranks ...int) PokerRank {
+	value := uint32(category) << pokerCategoryShift
+	shift := uint(16)
+	for _, r := range ranks {
+		value |= uint32(r) << shift
+		shift -= 4
+	}
+	return PokerRank(value)
+}
+
+// Category returns the kind of poker hand
+func (r PokerRank) Category() PokerCategory {
+	return PokerCategory(r >> pokerCategoryShift)
+}
+
+// Ranks returns the card ranks (2 to 14, ace high) that break ties within the hand's category, most important first.
+// For example, a full house of kings over fives returns [13 5].
+func (r PokerRank) Ranks() []int {
+	ranks := make([]int, 0, 5)
+	for shift := 16; shift >= 0; shift -= 4 {
+		rank := int(r>>uint(shift)) & 0xF
+		if rank == 0 {
+			break
+		}
+		ranks = append(ranks, rank)
+	}
+	return ranks
+}
+
+func (r PokerRank) String() string {
+	ranks := r.Ranks()
+	switch r.Category() {
+	case HighCard:
+		return fmt.Sprintf("High Card, %s", pokerRankName(ranks[0]))
+	case OnePair:
+		return fmt.Sprintf("Pair of %s", pokerRankPlural(ranks[0]))
+	case TwoPair:
+		return fmt.Sprintf("Two Pair, %s and %s", pokerRankPlural(ranks[0]), pokerRankPlural(ranks[1]))
+	case ThreeOfAKind:
+		return fmt.Sprintf("Three of a Kind, %s", pokerRankPlural(ranks[0]))
+	case Straight:
+		return fmt.Sprintf("Straight, %s high", pokerRankName(ranks[0]))
+	case Flush:
+		return fmt.Sprintf("Flush, %s high", pokerRankName(ranks[0]))
+	case FullHouse:
+		return fmt.Sprintf("Full House, %s over %s", pokerRankPlural(ranks[0]), pokerRankPlural(ranks[1]))
+	case FourOfAKind:
+		return fmt.Sprintf("Four of a Kind, %s", pokerRankPlural(ranks[0]))
+	case StraightFlush:
+		return fmt.Sprintf("Straight Flush, %s high", pokerRankName(ranks[0]))
+	case RoyalFlush:
+		return "Royal Flush"
+	case FiveOfAKind:
+		return fmt.Sprintf("Five of a Kind, %s", pokerRankPlural(ranks[0]))
+	default:
+		return "Invalid poker hand"
+	}
+}
+
+var pokerRankNames = [...]string{2: "Two", 3: "Three", 4: "Four", 5: "Five", 6: "Six", 7: "Seven", 8: "Eight", 9: "Nine",
+	10: "Ten", 11: "Jack", 12: "Queen", 13: "King", 14: "Ace"}
+
+func pokerRankName(rank int) string {
+	return pokerRankNames[rank]
+}
+
+func pokerRankPlural(rank int) string {
+	if rank == 6 {
+		return "Sixes"
+	}
+	return pokerRankNames[rank] + "s"
+}
+
+// straightHighTable maps a 15-bit rank mask (bit r set for rank r, ace at bit 14) to the highest card of the best
+// straight in the mask, or 0 if there is no straight
+var straightHighTable [1 << 15]uint8
+
+func init() {
+	for mask := 0; mask < len(straightHighTable); mask++ {
+		m := mask
+		if m&(1<<14) != 0 {
+			// The ace also plays low in a 5-4-3-2-A straight
+			m |= 1 << 1
+		}
+		for high := 14; high >= 5; high-- {
+			need := 0x1F << uint(high-4)
+			if m&need == need {
+				straightHighTable[mask] = uint8(high)
+				break
+			}
+		}
+	}
+}
+
+// pokerCardRank returns the rank of a card with aces high
+func pokerCardRank(c Card) int {
+	if c.number == 1 {
+		return 14
+	}
+	return c.number
+}
+
+func isJoker(c Card) bool {
+	return c.suit == RED_JOKER || c.suit == BLACK_JOKER
+}
+
+// topRanks writes the n highest ranks set in the mask to dst and returns the number written
+func topRanks(mask uint16, n int, dst []int) int {
+	written := 0
+	for written < n && mask != 0 {
+		high := bits.Len16(mask) - 1
+		dst[written] = high
+		written++
+		mask &^= 1 << uint(high)
+	}
+	return written
+}
+
+// evaluateNatural ranks 5 to 7 cards that contain no Joker cards
+func evaluateNatural(cards []Card) PokerRank {
+	var suitMasks [4]uint16
+	var counts [15]uint8
+	var all uint16
+	for _, c := range cards {
+		r := pokerCardRank(c)
+		counts[r]++
+		suitMasks[c.suit] |= 1 << uint(r)
+		all |= 1 << uint(r)
+	}
+
+	var kickers [5]int
+	best := PokerRank(0)
+
+	// Flushes and straight flushes only depend on the cards of a single suit
+	for _, mask := range suitMasks {
+		if bits.OnesCount16(mask) < 5 {
+			continue
+		}
+		var rank PokerRank
+		if high := int(straightHighTable[mask]); high == 14 {
+			rank = newPokerRank(RoyalFlush, 14)
+		} else if high > 0 {
+			rank = newPokerRank(StraightFlush, high)
+		} else {
+			n := topRanks(mask, 5, kickers[:])
+			rank = newPokerRank(Flush, kickers[:n]...)
+		}
+		if rank > best {
+			best = rank
+		}
+	}
+
+	// Collect the ranks by how many times they appear, highest first
+	var quads, trips, pairs int
+	var fives int
+	var tripRanks, pairRanks [3]int
+	for r := 14; r >= 2; r-- {
+		switch counts[r] {
+		case 0, 1:
+		case 2:
+			if pairs < len(pairRanks) {
+				pairRanks[pairs] = r
+			}
+			pairs++
+		case 3:
+			if trips < len(tripRanks) {
+				tripRanks[trips] = r
+			}
+			trips++
+		case 4:
+			if quads == 0 {
+				quads = r
+			}
+		default:
+			if fives == 0 {
+				fives = r
+			}
+		}
+	}
+
+	var rank PokerRank
+	switch {
+	case fives > 0:
+		rank = newPokerRank(FiveOfAKind, fives)
+	case quads > 0:
+		topRanks(all&^(1<<uint(quads)), 1, kickers[:])
+		rank = newPokerRank(FourOfAKind, quads, kickers[0])
+	case trips >= 1 && trips+pairs >= 2:
+		// The pair of a full house can also come from a second set of three
+		pair := 0
+		if trips >= 2 {
+			pair = tripRanks[1]
+		}
+		if pairs >= 1 && pairRanks[0] > pair {
+			pair = pairRanks[0]
+		}
+		rank = newPokerRank(FullHouse, tripRanks[0], pair)
+	case straightHighTable[all] > 0:
+		rank = newPokerRank(Straight, int(straightHighTable[all]))
+	case trips == 1:
+		// The set goes first, followed by its kickers, all in the same fixed array
+		kickers[0] = tripRanks[0]
+		n := topRanks(all&^(1<<uint(tripRanks[0])), 2, kickers[1:])
+		rank = newPokerRank(ThreeOfAKind, kickers[:n+1]...)
+	case pairs >= 2:
+		topRanks(all&^(1<<uint(pairRanks[0])|1<<uint(pairRanks[1])), 1, kickers[:])
+		rank = newPokerRank(TwoPair, pairRanks[0], pairRanks[1], kickers[0])
+	case pairs == 1:
+		kickers[0] = pairRanks[0]
+		n := topRanks(all&^(1<<uint(pairRanks[0])), 3, kickers[1:])
+		rank = newPokerRank(OnePair, kickers[:n+1]...)
+	default:
+		n := topRanks(all, 5, kickers[:])
+		rank = newPokerRank(HighCard, kickers[:n]...)
+	}
+	if rank > best {
+		best = rank
+	}
+	return best
+}
+
+// wildCandidates are the cards a wild Joker can stand in for
+var wildCandidates = newCardSet(0, false)
+
+// evaluateWild tries every substitute for the Joker cards at the given positions and returns the best rank.
+// Substitutes are tried in increasing order so that interchangeable Jokers are only checked once.
+func evaluateWild(cards []Card, jokers []int, from int) PokerRank {
+	if len(jokers) == 0 {
+		return evaluateNatural(cards)
+	}
+	best := PokerRank(0)
+	pos := jokers[0]
+	for i := from; i < len(wildCandidates); i++ {
+		cards[pos] = wildCandidates[i]
+		if rank := evaluateWild(cards, jokers[1:], i); rank > best {
+			best = rank
+		}
+	}
+	return best
+}
+
+// EvaluatePoker ranks the best 5-card poker hand that can be made from 5 to 7 cards.
+// Joker cards are wild and stand in for whichever card makes the best hand.
+func EvaluatePoker(cards []Card) (PokerRank, error) {
+	if len(cards) < 5 || len(cards) > 7 {
+		return 0, ErrPokerHandSize
+	}
+	var jokerBuf [7]int
+	jokers := jokerBuf[:0]
+	for i, c := range cards {
+		if isJoker(c) {
+			jokers = append(jokers, i)
+		}
+	}
+	if len(jokers) == 0 {
+		return evaluateNatural(cards), nil
+	}
+	var buf [7]Card
+	work := buf[:len(cards)]
+	copy(work, cards)
+	return evaluateWild(work, jokers, 0), nil
+}
+
+// ComparePokerHands returns a positive number if a is the better poker hand, a negative number if b is better,
+// or 0 if the hands tie
+func ComparePokerHands(a, b []Card) (int, error) {
+	rankA, err := EvaluatePoker(a)
+	if err != nil {
+		return 0, err
+	}
+	rankB, err := EvaluatePoker(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case rankA > rankB:
+		return 1, nil
+	case rankA < rankB:
+		return -1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// BestPokerHand returns the five cards out of 5 to 7 cards that make the best poker hand, along with its rank
+func BestPokerHand(cards []Card) ([]Card, PokerRank, error) {
+	rank, err := EvaluatePoker(cards)
+	if err != nil {
+		return nil, 0, err
+	}
+	n := len(cards)
+	var hand [5]Card
+	// Try every way of leaving out n-5 cards until a hand matches the overall best rank
+	for skipA := 0; skipA < n; skipA++ {
+		for skipB := skipA; skipB < n; skipB++ {
+			if (n == 5 && (skipA > 0 || skipB > 0)) || (n == 6 && skipA != skipB) || (n == 7 && skipA == skipB) {
+				continue
+			}
+			k := 0
+			for i, c := range cards {
+				if n > 5 && (i == skipA || i == skipB) {
+					continue
+				}
+				hand[k] = c
+				k++
+			}
+			if handRank, _ := EvaluatePoker(hand[:]); handRank == rank {
+				return append([]Card(nil), hand[:]...), rank, nil
+			}
+		}
+	}
+	return append([]Card(nil), cards[:5]...), rank, nil
+}
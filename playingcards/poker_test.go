@@ -0,0 +1,146 @@
+package playingcards
+
+import "testing"
+
+// parseCards builds cards from their short codes, failing the test on a bad code
+func parseCards(t testing.TB, codes ...string) []Card {
+	t.Helper()
+	cards := make([]Card, len(codes))
+	for i, code := range codes {
+		card, err := ParseCard(code)
+		if err != nil {
+			t.Fatalf("ParseCard(%q): %v", code, err)
+		}
+		cards[i] = card
+	}
+	return cards
+}
+
+// TestEvaluatePokerCategoryCounts ranks every 5-card hand of a deck and checks how many fall in each category
+func TestEvaluatePokerCategoryCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("enumerates all 2,598,960 hands")
+	}
+	want := map[PokerCategory]int{
+		HighCard:      1302540,
+		OnePair:       1098240,
+		TwoPair:       123552,
+		ThreeOfAKind:  54912,
+		Straight:      10200,
+		Flush:         5108,
+		FullHouse:     3744,
+		FourOfAKind:   624,
+		StraightFlush: 36,
+		RoyalFlush:    4,
+	}
+
+	deck := newCardSet(0, false)
+	counts := map[PokerCategory]int{}
+	total := 0
+	hand := make([]Card, 5)
+	for a := 0; a < len(deck); a++ {
+		hand[0] = deck[a]
+		for b := a + 1; b < len(deck); b++ {
+			hand[1] = deck[b]
+			for c := b + 1; c < len(deck); c++ {
+				hand[2] = deck[c]
+				for d := c + 1; d < len(deck); d++ {
+					hand[3] = deck[d]
+					for e := d + 1; e < len(deck); e++ {
+						hand[4] = deck[e]
+						rank, err := EvaluatePoker(hand)
+						if err != nil {
+							t.Fatal(err)
+						}
+						counts[rank.Category()]++
+						total++
+					}
+				}
+			}
+		}
+	}
+
+	if total != 2598960 {
+		t.Errorf("evaluated %d hands, want 2598960", total)
+	}
+	for category, n := range want {
+		if counts[category] != n {
+			t.Errorf("%v: got %d hands, want %d", category, counts[category], n)
+		}
+	}
+	// 40 straight flushes in total, 4 of them royal
+	if sf := counts[StraightFlush] + counts[RoyalFlush]; sf != 40 {
+		t.Errorf("got %d straight flushes including royals, want 40", sf)
+	}
+}
+
+func TestEvaluatePoker(t *testing.T) {
+	tests := []struct {
+		cards []string
+		want  string
+	}{
+		{[]string{"AS", "KS", "QS", "JS", "10S"}, "Royal Flush"},
+		{[]string{"5H", "4H", "3H", "2H", "AH"}, "Straight Flush, Five high"},
+		{[]string{"9C", "9D", "9H", "9S", "2D", "KD", "3C"}, "Four of a Kind, Nines"},
+		{[]string{"KC", "KD", "KH", "5S", "5D", "5C", "2H"}, "Full House, Kings over Fives"},
+		{[]string{"2D", "7D", "9D", "JD", "KD", "KS", "KH"}, "Flush, King high"},
+		{[]string{"AC", "2D", "3H", "4S", "5D"}, "Straight, Five high"},
+		{[]string{"6C", "6D", "6H", "AS", "2D"}, "Three of a Kind, Sixes"},
+		{[]string{"JC", "JD", "4H", "4S", "AD", "AH"}, "Two Pair, Aces and Jacks"},
+		{[]string{"QC", "QD", "2H", "7S", "9D"}, "Pair of Queens"},
+		{[]string{"AC", "JD", "8H", "4S", "2D"}, "High Card, Ace"},
+		{[]string{"AC", "AD", "AH", "AS", "RJ"}, "Five of a Kind, Aces"},
+		{[]string{"KS", "QS", "JS", "10S", "BJ"}, "Royal Flush"},
+	}
+	for _, test := range tests {
+		rank, err := EvaluatePoker(parseCards(t, test.cards...))
+		if err != nil {
+			t.Fatalf("%v: %v", test.cards, err)
+		}
+		if rank.String() != test.want {
+			t.Errorf("%v: got %q, want %q", test.cards, rank.String(), test.want)
+		}
+	}
+}
+
+func TestComparePokerHandsKickers(t *testing.T) {
+	a := parseCards(t, "QC", "QD", "AH", "7S", "3D")
+	b := parseCards(t, "QH", "QS", "KH", "7D", "3C")
+	if c, _ := ComparePokerHands(a, b); c <= 0 {
+		t.Errorf("pair of queens with an ace kicker should beat a king kicker, got %d", c)
+	}
+	b = parseCards(t, "QH", "QS", "AD", "7D", "3C")
+	if c, _ := ComparePokerHands(a, b); c != 0 {
+		t.Errorf("identical ranks should tie, got %d", c)
+	}
+	if _, err := EvaluatePoker(a[:4]); err != ErrPokerHandSize {
+		t.Errorf("4 cards: got %v, want ErrPokerHandSize", err)
+	}
+}
+
+func TestEvaluatePokerDoesNotAllocate(t *testing.T) {
+	hands := [][]Card{
+		parseCards(t, "6C", "6D", "6H", "AS", "2D", "9C", "JH"),
+		parseCards(t, "QC", "QD", "2H", "7S", "9D", "4C", "KH"),
+	}
+	for _, hand := range hands {
+		allocs := testing.AllocsPerRun(100, func() {
+			EvaluatePoker(hand)
+		})
+		if allocs != 0 {
+			t.Errorf("%v: %v allocations per evaluation, want 0", hand, allocs)
+		}
+	}
+}
+
+func BenchmarkEvaluatePoker(b *testing.B) {
+	deck := NewDeck(false)
+	deck.SetShuffler(NewSeededShuffler(1))
+	deck.Shuffle()
+	cards := deck.Cards()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := (i * 7) % (len(cards) - 7)
+		EvaluatePoker(cards[start : start+7])
+	}
+}
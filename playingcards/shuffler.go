@@ -0,0 +1,94 @@
+package playingcards
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+)
+
+// Shuffler puts n items in a random order by calling swap, like rand.Shuffle. *rand.Rand is a Shuffler.
+type Shuffler interface {
+	Shuffle(n int, swap func(i, j int))
+}
+
+// NewShuffler returns a Shuffler that gets its random numbers from src.
+// It is only safe for concurrent use if src is.
+func NewShuffler(src rand.Source) Shuffler {
+	return rand.New(src)
+}
+
+// NewSeededShuffler returns a Shuffler that always gives the same orders for the same seed,
+// so tests are deterministic and games can be replayed
+func NewSeededShuffler(seed int64) Shuffler {
+	return NewShuffler(rand.NewSource(seed))
+}
+
+// NewCryptoShuffler returns a Shuffler that gets its random numbers from crypto/rand. It is safe for concurrent use.
+func NewCryptoShuffler() Shuffler {
+	return NewShuffler(CryptoSource{})
+}
+
+// CryptoSource is a rand.Source that reads from crypto/rand. It is safe for concurrent use, and can't be seeded.
+type CryptoSource struct{}
+
+// Int63 returns a non-negative random 63-bit integer
+func (s CryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Uint64 returns a random 64-bit integer. It panics if crypto/rand fails, since it can't return an error.
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// Seed does nothing, a crypto/rand source can't be seeded
+func (CryptoSource) Seed(seed int64) {}
+
+// globalShuffler uses the global math/rand functions
+type globalShuffler struct{}
+
+func (globalShuffler) Shuffle(n int, swap func(i, j int)) {
+	rand.Shuffle(n, swap)
+}
+
+// lockedShuffler lets a Shuffler be used by several goroutines at once
+type lockedShuffler struct {
+	mu       sync.Mutex
+	shuffler Shuffler
+}
+
+func (l *lockedShuffler) Shuffle(n int, swap func(i, j int)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.shuffler.Shuffle(n, swap)
+}
+
+var (
+	defaultShuffler   Shuffler = globalShuffler{}
+	defaultShufflerMu sync.RWMutex
+)
+
+// SetDefaultShuffler sets the Shuffler used by decks that weren't given their own with Deck.SetShuffler.
+// Decks in different goroutines share it, so it is only used by one of them at a time.
+// By default, decks are shuffled with the global math/rand functions, and a nil Shuffler goes back to them.
+func SetDefaultShuffler(s Shuffler) {
+	defaultShufflerMu.Lock()
+	defer defaultShufflerMu.Unlock()
+	if s == nil {
+		defaultShuffler = globalShuffler{}
+		return
+	}
+	defaultShuffler = &lockedShuffler{shuffler: s}
+}
+
+// getDefaultShuffler returns the Shuffler set by SetDefaultShuffler
+func getDefaultShuffler() Shuffler {
+	defaultShufflerMu.RLock()
+	defer defaultShufflerMu.RUnlock()
+	return defaultShuffler
+}
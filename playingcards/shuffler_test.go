@@ -0,0 +1,49 @@
+package playingcards
+
+import (
+	"reflect"
+	"testing"
+)
+
+// shuffledTop shuffles a new deck without Jokers with the given Shuffler, or the default one if it is nil,
+// and returns its top cards in short notation
+func shuffledTop(s Shuffler, n int) []string {
+	deck := NewDeck(false)
+	deck.SetShuffler(s)
+	deck.Shuffle()
+	cards, _ := deck.Peek(n)
+	short := make([]string, len(cards))
+	for i, card := range cards {
+		short[i] = card.Short()
+	}
+	return short
+}
+
+func TestSeededShufflerIsDeterministic(t *testing.T) {
+	if !reflect.DeepEqual(shuffledTop(NewSeededShuffler(42), 52), shuffledTop(NewSeededShuffler(42), 52)) {
+		t.Error("two decks shuffled with the same seed are in different orders")
+	}
+	if reflect.DeepEqual(shuffledTop(NewSeededShuffler(42), 52), shuffledTop(NewSeededShuffler(43), 52)) {
+		t.Error("decks shuffled with seeds 42 and 43 are in the same order")
+	}
+	// The order for a seed must not change, or games recorded with -seed can't be replayed
+	want := []string{"7D", "4C", "5H", "JC", "3C"}
+	if got := shuffledTop(NewSeededShuffler(42), 5); !reflect.DeepEqual(got, want) {
+		t.Errorf("seed 42 put %v on top of the deck, want %v", got, want)
+	}
+}
+
+func TestSetDefaultShuffler(t *testing.T) {
+	t.Cleanup(func() { SetDefaultShuffler(nil) })
+
+	SetDefaultShuffler(NewSeededShuffler(7))
+	if got, want := shuffledTop(nil, 52), shuffledTop(NewSeededShuffler(7), 52); !reflect.DeepEqual(got, want) {
+		t.Errorf("a deck without its own Shuffler was shuffled into %v, want the default Shuffler's %v", got, want)
+	}
+
+	// nil goes back to math/rand instead of panicking on the next shuffle
+	SetDefaultShuffler(nil)
+	if got := shuffledTop(nil, 52); len(got) != 52 {
+		t.Errorf("the deck has %d cards after shuffling with the default Shuffler, want 52", len(got))
+	}
+}
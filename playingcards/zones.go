@@ -0,0 +1,197 @@
+package playingcards
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Names of the usual zones of a table. Each player also has their own zone, see PlayerZone.
+const (
+	ZoneTable   = "table"
+	ZoneDiscard = "discard"
+)
+
+// Errors returned when moving cards between zones
+var (
+	ErrEmptyPile    = errors.New("there are no cards in the pile")
+	ErrCardNotFound = errors.New("the card is not in the pile")
+)
+
+// PlayerZone returns the name of the zone holding a player's cards
+func PlayerZone(playerID string) string {
+	return "player:" + playerID
+}
+
+// Pile is an ordered stack of cards outside the deck, such as a discard pile. The last card put down is on top.
+type Pile struct {
+	cards []Card
+}
+
+// Size returns the number of cards in the pile
+func (p Pile) Size() int {
+	return len(p.cards)
+}
+
+// Cards returns a copy of the cards in the pile, in the order they were put down, so the top card is last
+func (p Pile) Cards() []Card {
+	cards := make([]Card, len(p.cards))
+	copy(cards, p.cards)
+	return cards
+}
+
+// Top returns the top card of the pile, or ErrEmptyPile
+func (p Pile) Top() (Card, error) {
+	if len(p.cards) == 0 {
+		return EmptyCard, ErrEmptyPile
+	}
+	return p.cards[len(p.cards)-1], nil
+}
+
+// Put puts the given cards on top of the pile, one after the other
+func (p *Pile) Put(cards ...Card) {
+	p.cards = append(p.cards, cards...)
+}
+
+// Take removes the top n cards of the pile and returns them, the top card first
+func (p *Pile) Take(n int) ([]Card, error) {
+	if n < 1 {
+		return nil, ErrInvalidCount
+	}
+	if n > len(p.cards) {
+		return nil, ErrNotEnoughCards
+	}
+	taken := make([]Card, n)
+	for i := range taken {
+		taken[i] = p.cards[len(p.cards)-1-i]
+	}
+	p.cards = p.cards[:len(p.cards)-n]
+	return taken, nil
+}
+
+// Remove takes the topmost card with the same face as the given card out of the pile, returning the removed card
+func (p *Pile) Remove(c Card) (Card, bool) {
+	for i := len(p.cards) - 1; i >= 0; i-- {
+		if p.cards[i].SameFace(c) {
+			removed := p.cards[i]
+			cards := make([]Card, 0, len(p.cards)-1)
+			cards = append(cards, p.cards[:i]...)
+			p.cards = append(cards, p.cards[i+1:]...)
+			return removed, true
+		}
+	}
+	return EmptyCard, false
+}
+
+// Clear removes every card from the pile and returns them, in the order they were put down
+func (p *Pile) Clear() []Card {
+	cards := p.cards
+	p.cards = nil
+	return cards
+}
+
+// Zones holds the named piles of cards that left a deck, such as the discard pile, the cards played to the table,
+// or the cards in each player's hand. The zero value has no cards in any zone.
+type Zones struct {
+	piles map[string]*Pile
+}
+
+// Zone returns the pile of the zone with the given name, creating it if needed
+func (z *Zones) Zone(name string) *Pile {
+	if z.piles == nil {
+		z.piles = make(map[string]*Pile)
+	}
+	pile, ok := z.piles[name]
+	if !ok {
+		pile = &Pile{}
+		z.piles[name] = pile
+	}
+	return pile
+}
+
+// Names returns the names of the zones that have cards in them, sorted
+func (z *Zones) Names() []string {
+	names := []string{}
+	for name, pile := range z.piles {
+		if pile.Size() > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Size returns the number of cards in every zone
+func (z *Zones) Size() int {
+	size := 0
+	for _, pile := range z.piles {
+		size += pile.Size()
+	}
+	return size
+}
+
+// Move moves the top n cards of one zone onto another, keeping them in the same order
+func (z *Zones) Move(from string, to string, n int) error {
+	taken, err := z.Zone(from).Take(n)
+	if err != nil {
+		return err
+	}
+	destination := z.Zone(to)
+	for i := len(taken) - 1; i >= 0; i-- {
+		destination.Put(taken[i])
+	}
+	return nil
+}
+
+// MoveCard moves the topmost card with the same face as the given card from one zone onto another,
+// returning the moved card, or ErrCardNotFound if the first zone doesn't have it
+func (z *Zones) MoveCard(from string, to string, c Card) (Card, error) {
+	card, ok := z.Zone(from).Remove(c)
+	if !ok {
+		return EmptyCard, ErrCardNotFound
+	}
+	z.Zone(to).Put(card)
+	return card, nil
+}
+
+// Deal draws n cards from the top of the deck onto a zone, and returns them in the order they were drawn
+func (z *Zones) Deal(d *Deck, to string, n int) ([]Card, error) {
+	cards, err := d.DrawCards(n)
+	if err != nil {
+		return nil, err
+	}
+	z.Zone(to).Put(cards...)
+	return cards, nil
+}
+
+// Reshuffle puts every card of a zone back into the deck and shuffles the deck, returning how many cards went back
+func (z *Zones) Reshuffle(d *Deck, from string) int {
+	cards := z.Zone(from).Clear()
+	d.AddToBottom(cards...)
+	d.Shuffle()
+	return len(cards)
+}
+
+// MarshalJSON stores the cards of each zone that isn't empty
+func (z Zones) MarshalJSON() ([]byte, error) {
+	stored := make(map[string][]Card, len(z.piles))
+	for name, pile := range z.piles {
+		if pile.Size() > 0 {
+			stored[name] = pile.cards
+		}
+	}
+	return json.Marshal(stored)
+}
+
+// UnmarshalJSON restores zones stored by MarshalJSON
+func (z *Zones) UnmarshalJSON(data []byte) error {
+	var stored map[string][]Card
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	z.piles = make(map[string]*Pile, len(stored))
+	for name, cards := range stored {
+		z.piles[name] = &Pile{cards: cards}
+	}
+	return nil
+}
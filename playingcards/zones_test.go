@@ -0,0 +1,206 @@
+package playingcards
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testDeck returns a deck holding the given cards, the top card first
+func testDeck(t testing.TB, codes ...string) Deck {
+	t.Helper()
+	cards := parseCards(t, codes...)
+	for i, j := 0, len(cards)-1; i < j; i, j = i+1, j-1 {
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return Deck{cards: cards, numDecks: 1}
+}
+
+// shorts writes cards in short notation, to compare orders
+func shorts(cards []Card) []string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Short()
+	}
+	return codes
+}
+
+func TestPile(t *testing.T) {
+	var p Pile
+	if _, err := p.Top(); err != ErrEmptyPile {
+		t.Errorf("Top of an empty pile returned %v, want ErrEmptyPile", err)
+	}
+	p.Put(parseCards(t, "AS", "KH", "QD")...)
+	if top, _ := p.Top(); top.Short() != "QD" {
+		t.Errorf("Top = %s, want the last card put down, QD", top.Short())
+	}
+	taken, err := p.Take(2)
+	if err != nil || !reflect.DeepEqual(shorts(taken), []string{"QD", "KH"}) {
+		t.Errorf("Take(2) = %v, %v, want [QD KH], the top card first", shorts(taken), err)
+	}
+	if _, err := p.Take(2); err != ErrNotEnoughCards {
+		t.Errorf("Take(2) with one card left returned %v, want ErrNotEnoughCards", err)
+	}
+	if _, err := p.Take(0); err != ErrInvalidCount {
+		t.Errorf("Take(0) returned %v, want ErrInvalidCount", err)
+	}
+	if cleared := p.Clear(); !reflect.DeepEqual(shorts(cleared), []string{"AS"}) || p.Size() != 0 {
+		t.Errorf("Clear returned %v and left %d cards", shorts(cleared), p.Size())
+	}
+}
+
+func TestPileRemove(t *testing.T) {
+	backing := parseCards(t, "AS", "KH", "AS", "QD")
+	p := Pile{cards: backing}
+	removed, ok := p.Remove(parseCards(t, "AS")[0])
+	if !ok || removed.Short() != "AS" {
+		t.Fatalf("Remove(AS) = %s, %v", removed.Short(), ok)
+	}
+	if got := shorts(p.Cards()); !reflect.DeepEqual(got, []string{"AS", "KH", "QD"}) {
+		t.Errorf("after removing the topmost AS the pile is %v, want [AS KH QD]", got)
+	}
+	// The pile must not write over cards that still share its old backing array
+	if got := shorts(backing); !reflect.DeepEqual(got, []string{"AS", "KH", "AS", "QD"}) {
+		t.Errorf("Remove changed the slice the pile was made from to %v", got)
+	}
+	if _, ok := p.Remove(parseCards(t, "2C")[0]); ok {
+		t.Error("Remove(2C) found a card the pile doesn't have")
+	}
+}
+
+func TestZones(t *testing.T) {
+	deck := testDeck(t, "AS", "KH", "QD", "JC", "10S")
+	var z Zones
+	dealt, err := z.Deal(&deck, PlayerZone("alice"), 3)
+	if err != nil || !reflect.DeepEqual(shorts(dealt), []string{"AS", "KH", "QD"}) {
+		t.Fatalf("Deal = %v, %v, want [AS KH QD]", shorts(dealt), err)
+	}
+	if deck.Size() != 2 {
+		t.Errorf("the deck has %d cards after dealing 3 of 5", deck.Size())
+	}
+	if _, err := z.Deal(&deck, PlayerZone("bob"), 3); err != ErrNotEnoughCards {
+		t.Errorf("dealing 3 cards from a deck of 2 returned %v, want ErrNotEnoughCards", err)
+	}
+
+	if err := z.Move(PlayerZone("alice"), ZoneTable, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := shorts(z.Zone(ZoneTable).Cards()); !reflect.DeepEqual(got, []string{"KH", "QD"}) {
+		t.Errorf("after moving the top 2 cards the table is %v, want them in the same order, [KH QD]", got)
+	}
+	if _, err := z.MoveCard(PlayerZone("alice"), ZoneDiscard, parseCards(t, "AS")[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := z.MoveCard(PlayerZone("alice"), ZoneDiscard, parseCards(t, "AS")[0]); err != ErrCardNotFound {
+		t.Errorf("moving a card the zone doesn't have returned %v, want ErrCardNotFound", err)
+	}
+	if names := z.Names(); !reflect.DeepEqual(names, []string{ZoneDiscard, ZoneTable}) {
+		t.Errorf("Names = %v, want only the zones with cards, sorted", names)
+	}
+	if z.Size() != 3 {
+		t.Errorf("Size = %d, want 3", z.Size())
+	}
+}
+
+func TestZonesReshuffle(t *testing.T) {
+	deck := NewDeck(false)
+	var z Zones
+	z.Deal(&deck, PlayerZone("alice"), 10)
+	z.Move(PlayerZone("alice"), ZoneDiscard, 4)
+	if n := z.Reshuffle(&deck, ZoneDiscard); n != 4 {
+		t.Errorf("Reshuffle put back %d cards, want 4", n)
+	}
+	if deck.Size() != 46 || z.Zone(ZoneDiscard).Size() != 0 || z.Zone(PlayerZone("alice")).Size() != 6 {
+		t.Errorf("after reshuffling the discard pile, the deck has %d cards, the discard pile %d and the hand %d, want 46, 0 and 6",
+			deck.Size(), z.Zone(ZoneDiscard).Size(), z.Zone(PlayerZone("alice")).Size())
+	}
+}
+
+func TestZonesJSON(t *testing.T) {
+	var z Zones
+	z.Zone(ZoneDiscard).Put(parseCards(t, "AS", "KH")...)
+	z.Zone(ZoneTable) // empty zones aren't stored
+	data, err := json.Marshal(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Zones
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if names := restored.Names(); !reflect.DeepEqual(names, []string{ZoneDiscard}) {
+		t.Errorf("restored zones are %v, want only the discard pile", names)
+	}
+	if got := shorts(restored.Zone(ZoneDiscard).Cards()); !reflect.DeepEqual(got, []string{"AS", "KH"}) {
+		t.Errorf("restored discard pile is %v, want [AS KH]", got)
+	}
+}
+
+func TestDeckPeek(t *testing.T) {
+	deck := testDeck(t, "AS", "KH", "QD")
+	peeked, err := deck.Peek(2)
+	if err != nil || !reflect.DeepEqual(shorts(peeked), []string{"AS", "KH"}) {
+		t.Errorf("Peek(2) = %v, %v, want [AS KH]", shorts(peeked), err)
+	}
+	if deck.Size() != 3 {
+		t.Errorf("Peek took cards out of the deck, %d are left", deck.Size())
+	}
+	if _, err := deck.Peek(4); err != ErrNotEnoughCards {
+		t.Errorf("Peek(4) returned %v, want ErrNotEnoughCards", err)
+	}
+	if _, err := deck.Peek(0); err != ErrInvalidCount {
+		t.Errorf("Peek(0) returned %v, want ErrInvalidCount", err)
+	}
+}
+
+func TestDeckCut(t *testing.T) {
+	deck := testDeck(t, "AS", "KH", "QD", "JC", "10S")
+	if err := deck.Cut(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := shorts(deck.Cards()); !reflect.DeepEqual(got, []string{"QD", "JC", "10S", "AS", "KH"}) {
+		t.Errorf("after Cut(2) the deck is %v, want the top 2 cards at the bottom", got)
+	}
+	for _, position := range []int{0, 5, -1} {
+		if err := deck.Cut(position); err != ErrInvalidPosition {
+			t.Errorf("Cut(%d) returned %v, want ErrInvalidPosition", position, err)
+		}
+	}
+}
+
+func TestDeckInsert(t *testing.T) {
+	deck := testDeck(t, "AS", "KH")
+	for _, insert := range []struct {
+		card     string
+		position int
+	}{{"2C", 0}, {"3C", 3}, {"4C", 2}} {
+		if err := deck.Insert(parseCards(t, insert.card)[0], insert.position); err != nil {
+			t.Fatalf("Insert(%s, %d): %v", insert.card, insert.position, err)
+		}
+	}
+	if got := shorts(deck.Cards()); !reflect.DeepEqual(got, []string{"2C", "AS", "4C", "KH", "3C"}) {
+		t.Errorf("after the inserts the deck is %v, want [2C AS 4C KH 3C]", got)
+	}
+	if err := deck.Insert(parseCards(t, "5C")[0], 6); err != ErrInvalidPosition {
+		t.Errorf("inserting below the bottom returned %v, want ErrInvalidPosition", err)
+	}
+}
+
+func TestDeckBottom(t *testing.T) {
+	deck := testDeck(t, "AS", "KH")
+	deck.AddToBottom(parseCards(t, "2C", "3C")...)
+	if got := shorts(deck.Cards()); !reflect.DeepEqual(got, []string{"AS", "KH", "3C", "2C"}) {
+		t.Errorf("after AddToBottom(2C, 3C) the deck is %v, want 2C lowest", got)
+	}
+	card, err := deck.DrawFromBottom()
+	if err != nil || card.Short() != "2C" {
+		t.Errorf("DrawFromBottom = %s, %v, want 2C", card.Short(), err)
+	}
+	if drawn := shorts(deck.Drawn()); !reflect.DeepEqual(drawn, []string{"2C"}) {
+		t.Errorf("Drawn = %v, want the card drawn from the bottom", drawn)
+	}
+	empty := Deck{}
+	if _, err := empty.DrawFromBottom(); err != ErrEmptyDeck {
+		t.Errorf("DrawFromBottom on an empty deck returned %v, want ErrEmptyDeck", err)
+	}
+}
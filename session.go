@@ -0,0 +1,14 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// Session is the part of the Discord API the bot's handlers use.
+// *discordgo.Session implements it, and so does the FakeSession tests use to run the handlers without Discord.
+type Session interface {
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error
+	InteractionResponse(interaction *discordgo.Interaction) (*discordgo.Message, error)
+	ChannelMessageSend(channelID string, content string) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error)
+}
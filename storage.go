@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// StorageVersion is the version of the stored server state format.
+// Bump it whenever StoredServerState changes, and add a migration from the previous version to storageMigrations.
+const StorageVersion = 4
+
+// storageMigrations upgrade raw stored server states, keyed by the version they upgrade from.
+// Each migration edits the decoded JSON object in place so it matches the next version's format.
+var storageMigrations = map[int]func(raw map[string]interface{}) error{
+	// Version 1 had a single deck and game per server, version 2 keeps them per channel.
+	// The old deck goes to the channel its game was running in, or else it is kept as the server's default deck,
+	// which the first channel to use a deck picks up.
+	1: func(raw map[string]interface{}) error {
+		channels := make(map[string]interface{})
+		if game, ok := raw["game"].(map[string]interface{}); ok {
+			channelID, _ := game["channelID"].(string)
+			channels[channelID] = map[string]interface{}{
+				"deck": raw["deck"],
+				"game": map[string]interface{}{"gameType": game["gameType"]},
+			}
+		} else if deck, ok := raw["deck"]; ok {
+			raw["defaultDeck"] = deck
+		}
+		delete(raw, "deck")
+		delete(raw, "game")
+		raw["channels"] = channels
+		return nil
+	},
+	// Version 3 adds the games of Klondike in progress, which older versions didn't have
+	2: func(raw map[string]interface{}) error {
+		raw["solitaire"] = map[string]interface{}{}
+		return nil
+	},
+	// Version 4 adds the default deck, which only states migrated from version 1 can have
+	3: func(raw map[string]interface{}) error {
+		return nil
+	},
+}
+
+// StoredGame is the metadata of a game that was running when a server state was saved
+type StoredGame struct {
+	GameType int `json:"gameType"`
+}
+
+// StoredChannel is the part of a ChannelState that survives restarts
+type StoredChannel struct {
+	Deck        playingcards.Deck  `json:"deck"`
+	Zones       playingcards.Zones `json:"zones"`
+	Game        *StoredGame        `json:"game,omitempty"`
+	LastShuffle *ShuffleProof      `json:"lastShuffle,omitempty"`
+}
+
+// StoredServerState is the part of a ServerState that survives restarts
+type StoredServerState struct {
+	Version       int                       `json:"version"`
+	GuildID       string                    `json:"guildID"`
+	CardsStyle    int                       `json:"cardsStyle"`
+	IncludeJokers bool                      `json:"includeJokers"`
+	NumDecks      int                       `json:"numDecks"`
+	Channels      map[string]*StoredChannel `json:"channels"`
+	Solitaire     map[string]*KlondikeGame  `json:"solitaire"`
+	DefaultDeck   *playingcards.Deck        `json:"defaultDeck,omitempty"`
+}
+
+// Storage saves and loads the state of Discord servers between restarts
+type Storage interface {
+	// Load returns the stored state of the given server, or nil if nothing was stored yet
+	Load(guildID string) (*StoredServerState, error)
+	// Save replaces the stored state of a server
+	Save(state *StoredServerState) error
+	// GuildIDs lists every server that has a stored state
+	GuildIDs() ([]string, error)
+	// SetAside moves a server's stored state out of the way when it can't be loaded,
+	// so saving a fresh state doesn't overwrite it
+	SetAside(guildID string) error
+}
+
+// storage is where server states are kept, or nil if they are only kept in memory
+var storage Storage
+
+// FileStorage keeps each server's state in its own JSON file inside a directory
+type FileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates a storage that keeps its files in the given directory, creating it when needed
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{dir: dir}
+}
+
+func (f *FileStorage) path(guildID string) string {
+	return filepath.Join(f.dir, guildID+".json")
+}
+
+// Load reads a server's file, upgrading it to the current format if it was saved by an older version of the bot
+func (f *FileStorage) Load(guildID string) (*StoredServerState, error) {
+	data, err := ioutil.ReadFile(f.path(guildID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeServerState(data)
+}
+
+// Save writes a server's file, replacing it in one step so a crash never leaves a half-written file behind
+func (f *FileStorage) Save(state *StoredServerState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.dir, state.GuildID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(state.GuildID))
+}
+
+// SetAside renames a server's file to end in ".corrupt" and the time, so it can be inspected or fixed by hand
+func (f *FileStorage) SetAside(guildID string) error {
+	return os.Rename(f.path(guildID), fmt.Sprintf("%s.corrupt-%d", f.path(guildID), time.Now().Unix()))
+}
+
+// GuildIDs lists the servers with a file in the storage directory
+func (f *FileStorage) GuildIDs() ([]string, error) {
+	files, err := ioutil.ReadDir(f.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			ids = append(ids, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return ids, nil
+}
+
+// decodeServerState parses a stored server state, running any migrations needed to reach StorageVersion
+func decodeServerState(data []byte) (*StoredServerState, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	v, ok := raw["version"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("stored state has no version")
+	}
+	version := int(v)
+	if version > StorageVersion {
+		return nil, fmt.Errorf("stored state has version %d, but this bot only understands up to version %d", version, StorageVersion)
+	}
+	for ; version < StorageVersion; version++ {
+		migrate, ok := storageMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from stored state version %d", version)
+		}
+		if err := migrate(raw); err != nil {
+			return nil, fmt.Errorf("migrating stored state from version %d: %v", version, err)
+		}
+	}
+	raw["version"] = StorageVersion
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var state StoredServerState
+	if err := json.Unmarshal(upgraded, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// snapshot copies the parts of the server state that should survive restarts. The state must be locked.
+func (s *ServerState) snapshot() *StoredServerState {
+	stored := &StoredServerState{
+		Version:       StorageVersion,
+		GuildID:       s.id,
+		CardsStyle:    s.cardsStyle,
+		IncludeJokers: s.includeJokers,
+		NumDecks:      s.numDecks,
+		Channels:      make(map[string]*StoredChannel, len(s.channels)),
+		Solitaire:     s.solitaire,
+		DefaultDeck:   s.defaultDeck,
+	}
+	for id, channel := range s.channels {
+		if channel.GameType() == NoGame {
+			stored.Channels[id] = &StoredChannel{Deck: channel.deck, Zones: channel.zones}
+		} else {
+			// A running game may be dealing from the deck under its own lock, so only its metadata is stored
+			stored.Channels[id] = &StoredChannel{Deck: s.NewDeck(), Game: &StoredGame{GameType: channel.GameType()}}
+		}
+		stored.Channels[id].LastShuffle = channel.lastShuffle
+	}
+	return stored
+}
+
+// save writes the server state to storage, if there is one. The state must be locked.
+func (s *ServerState) save() {
+	if storage == nil || s.unsaved {
+		return
+	}
+	if err := storage.Save(s.snapshot()); err != nil {
+		log.Printf("Error saving the state of server %s: %v", s.id, err)
+	}
+}
+
+// restoreServerState rebuilds a server state from storage
+func restoreServerState(stored *StoredServerState) *ServerState {
+	state := NewServerState(stored.GuildID)
+	state.cardsStyle = stored.CardsStyle
+	state.includeJokers = stored.IncludeJokers
+	if stored.NumDecks > 0 {
+		state.numDecks = stored.NumDecks
+	}
+	for id, channel := range stored.Channels {
+		state.channels[id] = &ChannelState{id: id, deck: channel.Deck, zones: channel.Zones, lastShuffle: channel.LastShuffle}
+	}
+	for userID, game := range stored.Solitaire {
+		state.solitaire[userID] = game
+	}
+	state.defaultDeck = stored.DefaultDeck
+	return state
+}
+
+// interruptedGame is a game that was running in a channel when the bot last stopped
+type interruptedGame struct {
+	guildID   string
+	channelID string
+	gameType  int
+}
+
+// interruptedGames returns the games that were running when the bot last stopped.
+// Their tables and timers were lost with the old process, so they can only be reported and cleared.
+func interruptedGames() []interruptedGame {
+	games := []interruptedGame{}
+	if storage == nil {
+		return games
+	}
+	ids, err := storage.GuildIDs()
+	if err != nil {
+		log.Println("Error listing stored servers,", err)
+		return games
+	}
+	for _, id := range ids {
+		stored, err := storage.Load(id)
+		if err != nil {
+			log.Printf("Error loading the state of server %s: %v", id, err)
+			continue
+		}
+		if stored == nil {
+			continue
+		}
+		for channelID, channel := range stored.Channels {
+			if channel.Game != nil {
+				games = append(games, interruptedGame{guildID: id, channelID: channelID, gameType: channel.Game.GameType})
+			}
+		}
+	}
+	return games
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// useStorage makes the bot keep server states in s for the rest of the test
+func useStorage(t *testing.T, s Storage) {
+	previous := storage
+	storage = s
+	t.Cleanup(func() { storage = previous })
+}
+
+func TestUnreadableStateIsSetAside(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	useStorage(t, NewFileStorage(dir))
+	guildID := testGuild("corrupt")
+	corrupt := []byte(`{"version": 3, "channels": `)
+	if err := ioutil.WriteFile(filepath.Join(dir, guildID+".json"), corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state := GetServerState(guildID)
+	state.mu.Lock()
+	state.save()
+	state.mu.Unlock()
+
+	files, _ := ioutil.ReadDir(dir)
+	setAside := ""
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), guildID+".json.corrupt-") {
+			setAside = file.Name()
+		}
+	}
+	if setAside == "" {
+		t.Fatalf("the unreadable file wasn't set aside, the directory has %v", files)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, setAside)); string(data) != string(corrupt) {
+		t.Errorf("the file set aside holds %q, want the original %q", data, corrupt)
+	}
+	if stored, err := storage.Load(guildID); err != nil || stored == nil {
+		t.Errorf("the fresh state wasn't saved: %v", err)
+	}
+}
+
+// brokenStorage fails to load or set aside anything, and counts the saves
+type brokenStorage struct {
+	saves int
+}
+
+func (b *brokenStorage) Load(guildID string) (*StoredServerState, error) {
+	return nil, errors.New("unreadable")
+}
+
+func (b *brokenStorage) Save(state *StoredServerState) error {
+	b.saves++
+	return nil
+}
+
+func (b *brokenStorage) GuildIDs() ([]string, error) {
+	return nil, nil
+}
+
+func (b *brokenStorage) SetAside(guildID string) error {
+	return errors.New("read-only")
+}
+
+func TestUnreadableStateIsNotOverwritten(t *testing.T) {
+	broken := &brokenStorage{}
+	useStorage(t, broken)
+	f := NewFakeSession()
+	f.Command(testGuild("broken"), "channel", "user", "shuffle")
+	if broken.saves != 0 {
+		t.Errorf("saved %d times over a state that couldn't be loaded or set aside", broken.saves)
+	}
+}
+
+// Stored server states as older versions of the bot saved them
+const (
+	storedV1 = `{"version":1,"guildID":"g1","cardsStyle":1,"includeJokers":false,"numDecks":1,
+		"deck":{"cards":[{"value":2,"suit":0},{"value":13,"suit":2},{"value":1,"suit":3}],"numDecks":1}}`
+	storedV1WithGame = `{"version":1,"guildID":"g1","cardsStyle":0,"includeJokers":false,"numDecks":2,
+		"deck":{"cards":[{"value":5,"suit":1}],"numDecks":2},"game":{"gameType":1,"channelID":"c1"}}`
+	storedV2 = `{"version":2,"guildID":"g2","cardsStyle":1,"includeJokers":true,"numDecks":1,
+		"channels":{"c2":{"deck":{"cards":[{"value":12,"suit":3}],"numDecks":1},"zones":{"discard":[{"value":3,"suit":0}]}}}}`
+)
+
+func TestMigrations(t *testing.T) {
+	// Without a game, the version 1 deck becomes the server's default deck
+	stored, err := decodeServerState([]byte(storedV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != StorageVersion || stored.CardsStyle != 1 || len(stored.Channels) != 0 || stored.Solitaire == nil {
+		t.Errorf("migrated version 1 state is %+v", stored)
+	}
+	if stored.DefaultDeck == nil || stored.DefaultDeck.Size() != 3 {
+		t.Fatalf("the version 1 deck wasn't kept as the default deck: %+v", stored.DefaultDeck)
+	}
+	state := restoreServerState(stored)
+	if deck := state.Channel("first").deck; deck.Size() != 3 || deck.Cards()[0].Short() != "AS" {
+		t.Errorf("the first channel got a deck of %d cards, want the 3 cards of the old deck", deck.Size())
+	}
+	if deck := state.Channel("second").deck; deck.Size() != 52 {
+		t.Errorf("the second channel got a deck of %d cards, want a new deck", deck.Size())
+	}
+
+	// With a game, the version 1 deck goes to the game's channel
+	stored, err = decodeServerState([]byte(storedV1WithGame))
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel := stored.Channels["c1"]
+	if channel == nil || channel.Game == nil || channel.Game.GameType != 1 || channel.Deck.Size() != 1 || channel.Deck.NumDecks() != 2 {
+		t.Errorf("the version 1 game and deck didn't move to their channel: %+v", channel)
+	}
+	if stored.DefaultDeck != nil {
+		t.Error("the version 1 deck was kept twice")
+	}
+
+	// Version 2 states gain an empty set of Klondike games, and keep their channels
+	stored, err = decodeServerState([]byte(storedV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel = stored.Channels["c2"]
+	if stored.Solitaire == nil || !stored.IncludeJokers || channel == nil || channel.Deck.Size() != 1 ||
+		channel.Zones.Zone(playingcards.ZoneDiscard).Size() != 1 || stored.DefaultDeck != nil {
+		t.Errorf("migrated version 2 state is %+v", stored)
+	}
+
+	if _, err := decodeServerState([]byte(`{"version":99}`)); err == nil {
+		t.Error("a state from a newer version of the bot was read")
+	}
+}
+
+// TestDefaultDeckIsSaved checks that a default deck no channel has picked up yet survives another restart
+func TestDefaultDeckIsSaved(t *testing.T) {
+	stored, err := decodeServerState([]byte(storedV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := restoreServerState(stored)
+	data, err := json.Marshal(state.snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := decodeServerState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DefaultDeck == nil || again.DefaultDeck.Size() != 3 {
+		t.Errorf("the default deck was lost after saving again: %+v", again.DefaultDeck)
+	}
+	state.resetIdleDecks()
+	if state.Channel("first").deck.Size() != 52 {
+		t.Error("changing the deck settings kept the old default deck")
+	}
+}
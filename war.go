@@ -0,0 +1,600 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+func init() {
+	RegisterGame(&GameInfo{
+		Type: War,
+		Name: "War",
+		Command: &discordgo.ApplicationCommand{
+			Name:        "war",
+			Description: "Start a game of War for two or more players.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "auto",
+					Description: "Let the bot flip the cards and narrate every round (default false)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "speed",
+					Description: fmt.Sprintf("Seconds between rounds when playing automatically (default %d)", DefaultWarSpeed),
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    maxWarSpeedOptionValue,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max-rounds",
+					Description: fmt.Sprintf("The game stops after this many rounds (default %d)", DefaultWarMaxRounds),
+					MinValue:    &integerOptionMinValue,
+					MaxValue:    maxWarRoundsOptionValue,
+				},
+			},
+		},
+		CommandHandler:   warCommand,
+		ComponentPrefix:  "war",
+		ComponentHandler: warComponent,
+	})
+}
+
+// Defaults and limits for games of War
+const (
+	DefaultWarSpeed     = 3
+	DefaultWarMaxRounds = 300
+	MaxWarPlayers       = 6
+	// warFaceDownCards is how many cards each player puts face down in a war before turning one up
+	warFaceDownCards = 3
+	warFlipTimeout   = 30 * time.Second
+)
+
+var (
+	maxWarSpeedOptionValue  = 10.0
+	maxWarRoundsOptionValue = 1000.0
+)
+
+type warPlayer struct {
+	userID string
+	// cards is the player's face down pile, the top card first. Won cards go to the bottom.
+	cards   playingcards.Hand
+	flipped bool
+}
+
+// WarGame holds the state of a game of War
+type WarGame struct {
+	mu         sync.Mutex
+	guildID    string
+	channelID  string
+	messageID  string
+	cardsStyle int
+	auto       bool
+	speed      time.Duration
+	maxRounds  int
+	deck       *playingcards.Deck
+
+	players   []*warPlayer
+	started   bool
+	over      bool
+	closed    bool
+	round     int
+	flipToken int
+	// shown holds the face up cards that decided the last round, in player order
+	shown []playingcards.Card
+	log   []string
+}
+
+// NewWarGame creates a game of War that players can join. The cards are split from deck,
+// which the game owns until it is over.
+func NewWarGame(guildID string, channelID string, cardsStyle int, deck *playingcards.Deck, auto bool, speed time.Duration, maxRounds int) *WarGame {
+	return &WarGame{
+		guildID:    guildID,
+		channelID:  channelID,
+		cardsStyle: cardsStyle,
+		deck:       deck,
+		auto:       auto,
+		speed:      speed,
+		maxRounds:  maxRounds,
+	}
+}
+
+// Lock locks the game's state
+func (g *WarGame) Lock() {
+	g.mu.Lock()
+}
+
+// Unlock unlocks the game's state
+func (g *WarGame) Unlock() {
+	g.mu.Unlock()
+}
+
+// End stops the game, so pending timers and button presses are ignored
+func (g *WarGame) End() {
+	g.closed = true
+}
+
+func (g *WarGame) player(userID string) *warPlayer {
+	for _, player := range g.players {
+		if player.userID == userID {
+			return player
+		}
+	}
+	return nil
+}
+
+// inGame returns the players who still have cards
+func (g *WarGame) inGame() []*warPlayer {
+	players := []*warPlayer{}
+	for _, player := range g.players {
+		if player.cards.Size() > 0 {
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// Join adds a player before the game starts
+func (g *WarGame) Join(userID string) error {
+	if g.started {
+		return errors.New("The game has already started.")
+	}
+	if g.player(userID) != nil {
+		return errors.New("You already joined the game.")
+	}
+	if len(g.players) >= MaxWarPlayers {
+		return errors.New("The game is full.")
+	}
+	g.players = append(g.players, &warPlayer{userID: userID})
+	return nil
+}
+
+// Start shuffles the deck and splits it between the players
+func (g *WarGame) Start(userID string) (bool, error) {
+	if g.started {
+		return false, errors.New("The game has already started.")
+	}
+	if g.player(userID) == nil {
+		return false, errors.New("Only players in the game can start it.")
+	}
+	if len(g.players) < 2 {
+		return false, errors.New("War needs at least two players.")
+	}
+	g.deck.Shuffle()
+	for n := 0; g.deck.Size() > 0; n++ {
+		g.players[n%len(g.players)].cards.Add(g.deck.DrawCard())
+	}
+	g.started = true
+	g.log = []string{"The deck was split between the players."}
+	return false, nil
+}
+
+// Action flips the player's card for the round. The round is played once everyone has flipped, and then it returns true.
+func (g *WarGame) Action(userID string, action string, amount int) (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	if action != "flip" {
+		return false, errors.New("Unknown action.")
+	}
+	player := g.player(userID)
+	if player == nil {
+		return false, errors.New("You are not playing in this game.")
+	}
+	if player.cards.Size() == 0 {
+		return false, errors.New("You are out of cards.")
+	}
+	if player.flipped {
+		return false, errors.New("You already flipped your card this round.")
+	}
+	player.flipped = true
+	for _, other := range g.inGame() {
+		if !other.flipped {
+			return false, nil
+		}
+	}
+	g.playRound()
+	return true, nil
+}
+
+// Timeout flips the cards of every player who hasn't yet and plays the round. It returns true once the round is over.
+func (g *WarGame) Timeout() (bool, error) {
+	if !g.started {
+		return false, errors.New("The game hasn't started yet.")
+	}
+	if g.over {
+		return false, errors.New("The game is over.")
+	}
+	g.playRound()
+	return true, nil
+}
+
+// playRound has every player turn up their top card, and gives all the cards played to the highest one.
+// Players tied for the highest card go to war: they put cards face down and turn up another, until one wins.
+func (g *WarGame) playRound() {
+	g.round++
+	g.log = nil
+	pot := []playingcards.Card{}
+	contenders := g.inGame()
+	var winner *warPlayer
+	for winner == nil {
+		g.shown = nil
+		best := -1
+		leaders := []*warPlayer{}
+		turnedUp := make([]string, len(contenders))
+		for n, player := range contenders {
+			card, _ := player.cards.RemoveAt(0)
+			pot = append(pot, card)
+			g.shown = append(g.shown, card)
+			turnedUp[n] = fmt.Sprintf("%s %s", mention(player.userID), card.String())
+			rank := playingcards.AceHigh.Rank(card)
+			if rank > best {
+				best = rank
+				leaders = []*warPlayer{player}
+			} else if rank == best {
+				leaders = append(leaders, player)
+			}
+		}
+		g.log = append(g.log, strings.Join(turnedUp, " vs "))
+		if len(leaders) == 1 {
+			winner = leaders[0]
+			break
+		}
+
+		// War! Each tied player puts cards face down, keeping one to turn up next
+		mentions := make([]string, len(leaders))
+		contenders = []*warPlayer{}
+		for n, player := range leaders {
+			mentions[n] = mention(player.userID)
+			if player.cards.Size() == 0 {
+				g.log = append(g.log, fmt.Sprintf("%s has no cards left for the war.", mention(player.userID)))
+				continue
+			}
+			faceDown := minInt(warFaceDownCards, player.cards.Size()-1)
+			for k := 0; k < faceDown; k++ {
+				card, _ := player.cards.RemoveAt(0)
+				pot = append(pot, card)
+			}
+			contenders = append(contenders, player)
+		}
+		g.log = append(g.log, fmt.Sprintf("**War** between %s!", strings.Join(mentions, " and ")))
+		switch len(contenders) {
+		case 0:
+			// Nobody can carry on the war, so the tied players share the cards
+			for n, card := range pot {
+				leaders[n%len(leaders)].cards.Add(card)
+			}
+			g.log = append(g.log, "Nobody has cards left to carry on the war, so the cards are shared.")
+			pot = nil
+			contenders = nil
+		case 1:
+			winner = contenders[0]
+		}
+		if contenders == nil {
+			break
+		}
+	}
+	if winner != nil {
+		winner.cards.Add(pot...)
+		g.log = append(g.log, fmt.Sprintf("%s wins %d cards.", mention(winner.userID), len(pot)))
+	}
+
+	for _, player := range g.players {
+		player.flipped = false
+	}
+	inGame := g.inGame()
+	if len(inGame) <= 1 || g.round >= g.maxRounds {
+		g.over = true
+	}
+	g.flipToken++
+}
+
+// leaders returns the players with the most cards
+func (g *WarGame) leaders() []*warPlayer {
+	most := 0
+	leaders := []*warPlayer{}
+	for _, player := range g.players {
+		if player.cards.Size() > most {
+			most = player.cards.Size()
+			leaders = []*warPlayer{player}
+		} else if player.cards.Size() == most && most > 0 {
+			leaders = append(leaders, player)
+		}
+	}
+	return leaders
+}
+
+// Discord side of the game
+
+// warCommand handles the /war slash command by opening a game for players to join
+func warCommand(s Session, i *discordgo.InteractionCreate) {
+	state := GetServerState(i.GuildID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	channel := state.Channel(i.ChannelID)
+	if channel.GameType() != NoGame {
+		respondEphemeral(s, i, gameInProgressWarning())
+		return
+	}
+
+	auto, speed, maxRounds := false, DefaultWarSpeed, DefaultWarMaxRounds
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "auto":
+			auto = opt.BoolValue()
+		case "speed":
+			speed = int(opt.IntValue())
+		case "max-rounds":
+			maxRounds = int(opt.IntValue())
+		}
+	}
+
+	game := NewWarGame(i.GuildID, i.ChannelID, state.cardsStyle, channel.setStandardDeck(), auto, time.Duration(speed)*time.Second, maxRounds)
+	game.Join(interactionUserID(i))
+	channel.game = GameState{gameType: War, current: game}
+	state.save()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+			Components: warLobbyComponents(),
+		},
+	})
+}
+
+func (g *WarGame) rulesText() string {
+	if g.auto {
+		if g.speed == time.Second {
+			return fmt.Sprintf("The bot plays a round every second, up to %d rounds", g.maxRounds)
+		}
+		return fmt.Sprintf("The bot plays a round every %d seconds, up to %d rounds", int(g.speed/time.Second), g.maxRounds)
+	}
+	return fmt.Sprintf("Everyone flips their card with the button, up to %d rounds", g.maxRounds)
+}
+
+func (g *WarGame) lobbyEmbed() *discordgo.MessageEmbed {
+	var players strings.Builder
+	for _, player := range g.players {
+		players.WriteString(fmt.Sprintf("%s\n", mention(player.userID)))
+	}
+	return &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       "War",
+		Description: "Press **Join** to play, then any player can press **Start** to split the deck.\nThe highest card wins each round, and ties go to war!",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  fmt.Sprintf("Players (%d/%d)", len(g.players), MaxWarPlayers),
+				Value: players.String(),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: g.rulesText(),
+		},
+	}
+}
+
+func warLobbyComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: "war:join"},
+				discordgo.Button{Label: "Start", Style: discordgo.PrimaryButton, CustomID: "war:start"},
+			},
+		},
+	}
+}
+
+// warComponent handles the buttons of a game of War
+func warComponent(s Session, i *discordgo.InteractionCreate) {
+	game, _ := runningGame(i, War).(*WarGame)
+	if game == nil {
+		respondEphemeral(s, i, "This game of War is no longer running.")
+		return
+	}
+	userID := interactionUserID(i)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if game.closed {
+		respondEphemeral(s, i, "This game of War is no longer running.")
+		return
+	}
+
+	switch i.MessageComponentData().CustomID {
+	case "war:join":
+		if err := game.Join(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: warLobbyComponents(),
+			},
+		})
+	case "war:start":
+		if _, err := game.Start(userID); err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{game.lobbyEmbed()},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		embed, components := game.render()
+		message, err := s.ChannelMessageSendComplex(game.channelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		})
+		if err != nil {
+			s.ChannelMessageSend(game.channelID, "Error found while running the game. Exiting...")
+			game.end()
+			return
+		}
+		game.messageID = message.ID
+		if game.auto {
+			go game.autoPlay(s)
+		} else {
+			game.scheduleTimeout(s)
+		}
+	case "war:flip":
+		if i.Message == nil || i.Message.ID != game.messageID {
+			respondEphemeral(s, i, "This round is already over.")
+			return
+		}
+		roundOver, err := game.Action(userID, "flip", 0)
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		embed, components := game.render()
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: components,
+			},
+		})
+		if roundOver {
+			game.afterRound()
+			if !game.closed {
+				game.scheduleTimeout(s)
+			}
+		}
+	}
+}
+
+// end stops the game and resets its channel. The game must be locked.
+func (g *WarGame) end() {
+	g.End()
+	endGame(g.guildID, g.channelID, g)
+}
+
+// afterRound ends the game once it is over. The game must be locked.
+func (g *WarGame) afterRound() {
+	if g.over {
+		g.end()
+	}
+}
+
+// update edits the game message to show the last round. The game must be locked.
+func (g *WarGame) update(s Session) {
+	embed, components := g.render()
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         g.messageID,
+		Channel:    g.channelID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+}
+
+// scheduleTimeout flips the cards of the players who take too long. The game must be locked.
+func (g *WarGame) scheduleTimeout(s Session) {
+	token := g.flipToken
+	time.AfterFunc(warFlipTimeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed || g.flipToken != token {
+			return
+		}
+		if _, err := g.Timeout(); err != nil {
+			return
+		}
+		g.log = append(g.log, "Some players took too long, so their cards were flipped for them.")
+		g.update(s)
+		g.afterRound()
+		if !g.closed {
+			g.scheduleTimeout(s)
+		}
+	})
+}
+
+// autoPlay plays and narrates a round at the game's speed until the game is over
+func (g *WarGame) autoPlay(s Session) {
+	for {
+		time.Sleep(g.speed)
+		g.mu.Lock()
+		if g.closed {
+			g.mu.Unlock()
+			return
+		}
+		g.Timeout()
+		g.update(s)
+		g.afterRound()
+		g.mu.Unlock()
+	}
+}
+
+// render builds the game message: the last round, how many cards each player has, and the Flip button
+func (g *WarGame) render() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var description strings.Builder
+	description.WriteString(strings.Join(g.log, "\n"))
+	description.WriteString("\n\n")
+	for _, player := range g.players {
+		status := ""
+		switch {
+		case player.cards.Size() == 0:
+			status = " (out)"
+		case !g.over && !g.auto && player.flipped:
+			status = " ✅"
+		}
+		description.WriteString(fmt.Sprintf("%s: %d cards%s\n", mention(player.userID), player.cards.Size(), status))
+	}
+	if g.over {
+		leaders := g.leaders()
+		mentions := make([]string, len(leaders))
+		for n, player := range leaders {
+			mentions[n] = mention(player.userID)
+		}
+		if len(g.inGame()) > 1 {
+			description.WriteString(fmt.Sprintf("\nThe game reached %d rounds. ", g.maxRounds))
+		} else {
+			description.WriteString("\n")
+		}
+		if len(leaders) == 1 {
+			description.WriteString(fmt.Sprintf("**%s wins the game!**", mentions[0]))
+		} else {
+			description.WriteString(fmt.Sprintf("**It's a tie between %s!**", strings.Join(mentions, " and ")))
+		}
+	}
+
+	title := "War"
+	if g.round > 0 {
+		title = fmt.Sprintf("War: Round #%d", g.round)
+	}
+	embed := &discordgo.MessageEmbed{
+		Color:       0x3dbb6b,
+		Title:       title,
+		Description: description.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: g.rulesText(),
+		},
+	}
+	if len(g.shown) > 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: GetHandURL(g.shown, g.cardsStyle, LayoutRow)}
+	}
+	if g.over || g.auto {
+		return embed, []discordgo.MessageComponent{}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Flip", Style: discordgo.PrimaryButton, CustomID: "war:flip", Emoji: discordgo.ComponentEmoji{Name: "🃏"}},
+			},
+		},
+	}
+}
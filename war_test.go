@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/svntax/PlayingCardsBot/playingcards"
+)
+
+// warTestGame returns a started game of War where each player holds the given cards, top card first
+func warTestGame(t *testing.T, maxRounds int, hands ...string) *WarGame {
+	t.Helper()
+	g := NewWarGame("guild", "channel", 0, &playingcards.Deck{}, false, 0, maxRounds)
+	for n, hand := range hands {
+		g.players = append(g.players, &warPlayer{userID: string(rune('a' + n)), cards: playingcards.NewHand(mustParseCards(t, hand)...)})
+	}
+	g.started = true
+	return g
+}
+
+func TestWarRound(t *testing.T) {
+	tests := []struct {
+		name  string
+		hands []string
+		// want is each player's pile after the round, top card first
+		want []string
+		over bool
+	}{
+		{
+			"the highest card wins",
+			[]string{"10C 2C", "3D 9D"},
+			[]string{"2C 10C 3D", "9D"},
+			false,
+		},
+		{
+			"tied players go to war and the winner takes every card",
+			[]string{"KC 2C 3C 4C 9H 5S", "KD 5C 6C 7C 8H 6S"},
+			[]string{"5S KC KD 2C 3C 4C 5C 6C 7C 9H 8H", "6S"},
+			false,
+		},
+		{
+			"only the tied players go to war",
+			[]string{"KC 2C 3C 4C 9H", "KD 5C 6C 7C 8H", "2S 3S"},
+			[]string{"KC KD 2S 2C 3C 4C 5C 6C 7C 9H 8H", "", "3S"},
+			false,
+		},
+		{
+			"a war that ties again goes on",
+			[]string{"KC 2C 3C 4C 9H 2D 3D 4D AH", "KD 5C 6C 7C 9S 5D 6D 7D QH"},
+			[]string{"KC KD 2C 3C 4C 5C 6C 7C 9H 9S 2D 3D 4D 5D 6D 7D AH QH", ""},
+			true,
+		},
+		{
+			"a tied player with one card left turns it up without cards face down",
+			[]string{"KC 9C", "KD 2C 3C 4C 5C"},
+			[]string{"KC KD 2C 3C 4C 9C 5C", ""},
+			true,
+		},
+		{
+			"a tied player out of cards loses the war",
+			[]string{"KC", "KD 5C 6C 7C 8H"},
+			[]string{"", "8H KC KD 5C 6C 7C"},
+			true,
+		},
+		{
+			"the tied players share the cards when nobody can carry on the war",
+			[]string{"KC", "KD", "2S"},
+			[]string{"KC 2S", "KD", ""},
+			false,
+		},
+	}
+	for _, test := range tests {
+		g := warTestGame(t, DefaultWarMaxRounds, test.hands...)
+		g.playRound()
+		for n, player := range g.players {
+			if got := cardNames(player.cards.Cards()); got != cardNames(mustParseCards(t, test.want[n])) {
+				t.Errorf("%s: player %d has %s, want %s", test.name, n, got, test.want[n])
+			}
+		}
+		if g.over != test.over {
+			t.Errorf("%s: over is %v, want %v", test.name, g.over, test.over)
+		}
+	}
+}
+
+func TestWarMaxRounds(t *testing.T) {
+	g := warTestGame(t, 2, "10C 2C 3C", "3D 9D 4D")
+	g.playRound()
+	if g.over {
+		t.Fatal("the game ended before reaching the round limit")
+	}
+	g.playRound()
+	if !g.over {
+		t.Fatal("the game didn't end at the round limit")
+	}
+	// Each player won a round and has three cards left, so they share the win
+	if leaders := g.leaders(); len(leaders) != 2 {
+		t.Errorf("%d players lead after the round limit, want a tie between both", len(leaders))
+	}
+
+	g = warTestGame(t, 1, "10C 2C", "3D 9D 4D")
+	g.playRound()
+	if leaders := g.leaders(); !g.over || len(leaders) != 1 || leaders[0].userID != "a" {
+		t.Errorf("after the round limit, over is %v and %d players lead, want player a to win", g.over, len(leaders))
+	}
+}
+
+func mustParseCards(t *testing.T, s string) []playingcards.Card {
+	t.Helper()
+	cards, err := playingcards.ParseCards(s)
+	if err != nil {
+		t.Fatalf("ParseCards(%q): %v", s, err)
+	}
+	return cards
+}